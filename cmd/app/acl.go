@@ -0,0 +1,87 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/pkg/plugin/traffic_control"
+)
+
+// The acl report cross-references the acl plugins applied to a route (directly, through its service or globally)
+// with the groups of every consumer, to show exactly which consumers are admitted.
+
+var ACLCommand = cli.Command{
+	Name:  "acl",
+	Usage: "The kong acl access report.",
+
+	Subcommands: []cli.Command{
+		{
+			Name:  "who-can-access",
+			Usage: "list the consumers admitted by the acl plugin applied to a route",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "route",
+					Usage: "the route id",
+				},
+			},
+			Action: whoCanAccess,
+		},
+	},
+}
+
+//whoCanAccess print every consumer and whether the acl plugin of the route admits it.
+func whoCanAccess(c *cli.Context) error {
+	routeID := c.String("route")
+	if routeID == "" {
+		return fmt.Errorf("route id is empty")
+	}
+
+	route := &RouteConfig{}
+	if err := getObject(fmt.Sprintf("%s/%s", ROUTE_RESOURCE_OBJECT, routeID), route); err != nil {
+		return err
+	}
+
+	plugins, err := fetchAllPlugins(traffic_control.PLUGIN_ACL)
+	if err != nil {
+		return err
+	}
+
+	plugin, scope := resolveRoutePlugin(plugins, traffic_control.PLUGIN_ACL, route.ID, route.Service.ID)
+	if plugin == nil {
+		fmt.Printf("no acl plugin applies to route %s, every consumer is admitted.\n", route.ID)
+		return nil
+	}
+
+	cfg := traffic_control.ACLConfig{}
+	if err := json.Unmarshal(plugin.Config, &cfg); err != nil {
+		return err
+	}
+
+	fmt.Printf("acl plugin %s applied on %s, whitelist: [%s] blacklist: [%s]\n\n",
+		plugin.ID, scope, strings.Join(cfg.Whitelist, ","), strings.Join(cfg.Blacklist, ","))
+
+	consumers, err := fetchAllConsumers()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-40s\t%-20s\t%-30s\t%-10s\n", "ID", "USERNAME", "GROUPS", "ADMITTED")
+	for _, consumer := range consumers {
+		acls, err := traffic_control.GetConsumerACLGroups(consumer.ID)
+		if err != nil {
+			return err
+		}
+
+		groups := make([]string, 0, len(acls))
+		for _, g := range acls {
+			groups = append(groups, g.Group)
+		}
+
+		fmt.Printf("%-40s\t%-20s\t%-30s\t%-10t\n", consumer.ID, consumer.Username, strings.Join(groups, ","), traffic_control.ACLAdmits(cfg, groups))
+	}
+
+	return nil
+}
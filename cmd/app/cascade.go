@@ -0,0 +1,190 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// A cascading delete collects the entities depending on a service or a upstream, prints them as a tree,
+// then deletes them bottom-up after confirmation, so no route or plugin is left pointing to a deleted entity.
+// Kong 0.14 deletes the targets of a upstream with it, deleting a target only adds a weight 0 entry,
+// so the targets are shown but not deleted one by one.
+
+//dependency is an entity and the entities depending on it.
+type dependency struct {
+	Kind       string
+	ID         string
+	Name       string
+	requestURL string
+	//Deleted by kong with its parent, it is not deleted on its own.
+	cascaded bool
+	Children []*dependency
+}
+
+func (d *dependency) String() string {
+	if d.Name != "" {
+		return fmt.Sprintf("%s %s (%s)", d.Kind, d.Name, d.ID)
+	}
+	return fmt.Sprintf("%s %s", d.Kind, d.ID)
+}
+
+//print write the tree of the dependencies.
+func (d *dependency) print(prefix, branch string) {
+	note := ""
+	if d.cascaded {
+		note = ", deleted by kong with its parent"
+	}
+	fmt.Printf("%s%s%s%s\n", prefix, branch, d, note)
+
+	switch branch {
+	case "├── ":
+		prefix += "│   "
+	case "└── ":
+		prefix += "    "
+	}
+
+	for i, child := range d.Children {
+		if i == len(d.Children)-1 {
+			child.print(prefix, "└── ")
+		} else {
+			child.print(prefix, "├── ")
+		}
+	}
+}
+
+//count return the number of entities of the tree.
+func (d *dependency) count() int {
+	n := 1
+	for _, child := range d.Children {
+		n += child.count()
+	}
+	return n
+}
+
+//deletionOrder return the entities of the tree, the children before their parent.
+func (d *dependency) deletionOrder() []*dependency {
+	var order []*dependency
+	for _, child := range d.Children {
+		order = append(order, child.deletionOrder()...)
+	}
+	return append(order, d)
+}
+
+//delete delete the entities of the tree bottom-up, a failure reports the entities deleted and remaining.
+func (d *dependency) delete() error {
+	order := d.deletionOrder()
+
+	var deleted []string
+	for i, dep := range order {
+		if dep.cascaded {
+			continue
+		}
+
+		if err := deleteObject(dep.requestURL); err != nil {
+			var remaining []string
+			for _, r := range order[i:] {
+				remaining = append(remaining, r.String())
+			}
+			if len(deleted) == 0 {
+				deleted = []string{"none"}
+			}
+			return fmt.Errorf("failed to delete %s: %v\ndeleted: %s\nremaining: %s", dep, err, strings.Join(deleted, ", "), strings.Join(remaining, ", "))
+		}
+
+		deleted = append(deleted, dep.String())
+		fmt.Printf("delete %s\n", dep)
+	}
+	return nil
+}
+
+//serviceDependencies return the service with its plugins, and its routes with their plugins.
+func serviceDependencies(service string) (*dependency, error) {
+	s := &ServiceConfig{}
+	if err := getObject(fmt.Sprintf("%s/%s", SERVICE_RESOURCE_OBJECT, service), s); err != nil {
+		return nil, err
+	}
+
+	root := &dependency{Kind: "service", ID: s.ID, Name: s.Name, requestURL: fmt.Sprintf("%s/%s", SERVICE_RESOURCE_OBJECT, s.ID)}
+
+	plugins, err := fetchAllPlugins("")
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := listAllObjects(fmt.Sprintf("%s/%s/routes", SERVICE_RESOURCE_OBJECT, s.ID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]RouteConfig, 0, len(objects))
+	for _, raw := range objects {
+		r := RouteConfig{}
+		if err := json.Unmarshal(raw, &r); err != nil {
+			return nil, err
+		}
+		routes = append(routes, r)
+	}
+
+	//a plugin on a route and the service is listed once, under its route.
+	seen := map[string]bool{}
+	pluginsOf := func(match func(p CommonPluginConfig) bool) []*dependency {
+		var deps []*dependency
+		for _, p := range plugins {
+			if match(p) && !seen[p.ID] {
+				seen[p.ID] = true
+				deps = append(deps, &dependency{Kind: "plugin", ID: p.ID, Name: p.Name, requestURL: fmt.Sprintf("%s/%s", PLUGIN_RESOURCE_OBJECT, p.ID)})
+			}
+		}
+		return deps
+	}
+
+	var children []*dependency
+	for _, r := range routes {
+		id := r.ID
+		route := &dependency{Kind: "route", ID: r.ID, Name: strings.Join(append(r.Hosts, r.Paths...), " "), requestURL: fmt.Sprintf("%s/%s", ROUTE_RESOURCE_OBJECT, r.ID)}
+		route.Children = pluginsOf(func(p CommonPluginConfig) bool { return p.RouteID.ID == id })
+		children = append(children, route)
+	}
+
+	root.Children = append(pluginsOf(func(p CommonPluginConfig) bool { return p.ServiceID.ID == s.ID }), children...)
+	return root, nil
+}
+
+//upstreamDependencies return the upstream with its targets, kong deletes them with the upstream.
+func upstreamDependencies(upstream string) (*dependency, error) {
+	u := &UpstreamConfig{}
+	if err := getObject(fmt.Sprintf("%s/%s", UPSTREAM_RESOURCE_OBJECT, upstream), u); err != nil {
+		return nil, err
+	}
+
+	root := &dependency{Kind: "upstream", ID: u.ID, Name: u.Name, requestURL: fmt.Sprintf("%s/%s", UPSTREAM_RESOURCE_OBJECT, u.ID)}
+
+	targets, err := listAllObjects(fmt.Sprintf("%s/%s/%s", UPSTREAM_RESOURCE_OBJECT, u.ID, TARGET_RESOURCE_OBJECT), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, raw := range targets {
+		t := TargetConfig{}
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return nil, err
+		}
+		root.Children = append(root.Children, &dependency{Kind: "target", ID: t.ID, Name: t.Target, cascaded: true})
+	}
+
+	return root, nil
+}
+
+//cascadeDelete print the dependency tree and delete it bottom-up after confirmation.
+func cascadeDelete(c *cli.Context, root *dependency) error {
+	root.print("", "")
+
+	if err := confirmDestructive(c, fmt.Sprintf("\ndelete these %d entities?", root.count()), resourceName(root.Name, root.ID), true); err != nil {
+		return err
+	}
+
+	return root.delete()
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestServiceDependencies(t *testing.T) {
+	defer fakeKong(t, map[string]fakeResponse{
+		"/services/orders":    {http.StatusOK, `{"id":"s1","name":"orders"}`},
+		"/services/s1/routes": {http.StatusOK, `{"data":[{"id":"r1","paths":["/orders"]},{"id":"r2","hosts":["orders.example.com"]}]}`},
+		"/plugins": {http.StatusOK, `{"data":[
+			{"id":"p1","name":"cors","service":{"id":"s1"}},
+			{"id":"p2","name":"key-auth","service":{"id":"s1"},"route":{"id":"r1"}},
+			{"id":"p3","name":"acl","route":{"id":"r2"}},
+			{"id":"p4","name":"cors","service":{"id":"s9"}}
+		]}`},
+	})()
+
+	root, err := serviceDependencies("orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, dep := range root.deletionOrder() {
+		got = append(got, dep.ID)
+	}
+
+	want := []string{"p1", "p2", "r1", "p3", "r2", "s1"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if n := root.count(); n != 6 {
+		t.Errorf("got %d entities, want 6", n)
+	}
+}
+
+func TestDependencyDelete(t *testing.T) {
+	root := &dependency{Kind: "service", ID: "s1", Name: "orders", requestURL: "services/s1", Children: []*dependency{
+		{Kind: "plugin", ID: "p1", requestURL: "plugins/p1"},
+		{Kind: "route", ID: "r1", requestURL: "routes/r1"},
+	}}
+
+	cases := []struct {
+		name      string
+		responses map[string]fakeResponse
+		requests  []string
+		errs      []string
+	}{
+		{
+			name: "every entity is deleted",
+			responses: map[string]fakeResponse{
+				"DELETE /plugins/p1":  {http.StatusNoContent, ``},
+				"DELETE /routes/r1":   {http.StatusNoContent, ``},
+				"DELETE /services/s1": {http.StatusNoContent, ``},
+			},
+			requests: []string{"DELETE /plugins/p1", "DELETE /routes/r1", "DELETE /services/s1"},
+		},
+		{
+			name: "a failure reports the deleted and remaining entities",
+			responses: map[string]fakeResponse{
+				"DELETE /plugins/p1": {http.StatusNoContent, ``},
+				"DELETE /routes/r1":  {http.StatusInternalServerError, `{"message":"An unexpected error occurred"}`},
+			},
+			requests: []string{"DELETE /plugins/p1", "DELETE /routes/r1"},
+			errs:     []string{"deleted: plugin p1", "remaining: route r1, service orders (s1)"},
+		},
+	}
+
+	for _, tc := range cases {
+		requests, restore := recordKong(t, tc.responses)
+		err := root.delete()
+		restore()
+
+		if strings.Join(*requests, ",") != strings.Join(tc.requests, ",") {
+			t.Errorf("%s: got requests %v, want %v", tc.name, *requests, tc.requests)
+		}
+		if (err != nil) != (len(tc.errs) > 0) {
+			t.Errorf("%s: got error %v", tc.name, err)
+			continue
+		}
+		for _, e := range tc.errs {
+			if !strings.Contains(err.Error(), e) {
+				t.Errorf("%s: error %q does not contain %q", tc.name, err, e)
+			}
+		}
+	}
+}
+
+func TestCascadeDeleteUpstream(t *testing.T) {
+	requests, restore := recordKong(t, map[string]fakeResponse{
+		"GET /upstreams/orders.upstream": {http.StatusOK, `{"id":"u1","name":"orders.upstream"}`},
+		"GET /upstreams/u1/targets":      {http.StatusOK, `{"data":[{"id":"t1","target":"10.0.0.1:80"},{"id":"t2","target":"10.0.0.2:80"}]}`},
+		"DELETE /upstreams/u1":           {http.StatusNoContent, ``},
+	})
+	defer restore()
+
+	root, err := upstreamDependencies("orders.upstream")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := newTestContext(t, []cli.Flag{cli.BoolFlag{Name: "yes"}}, "--yes")
+	if err := cascadeDelete(c, root); err != nil {
+		t.Fatal(err)
+	}
+
+	//the targets go with the upstream, a target DELETE would only add a weight 0 entry.
+	want := []string{"GET /upstreams/orders.upstream", "GET /upstreams/u1/targets", "DELETE /upstreams/u1"}
+	if strings.Join(*requests, ",") != strings.Join(want, ",") {
+		t.Errorf("got requests %v, want %v", *requests, want)
+	}
+}
@@ -4,6 +4,21 @@ import (
 	"github.com/urfave/cli"
 )
 
+// https://docs.konghq.com/0.14.x/admin-api/#certificate-object
+
+// A certificate object represents a public certificate/private key pair for an SSL certificate,
+// used by kong to handle SSL/TLS termination for encrypted requests. Certificates are associated with SNIs.
+
+const (
+	CERTIFICATE_RESOURCE_OBJECT = "certificates"
+)
+
+type CertificateConfig struct {
+	ID string `json:"id"`
+	//The SNIs associated with the certificate.
+	SNIs []string `json:"snis"`
+}
+
 //TODO
 var CertificateResourceObjectCommand = cli.Command{
 	Name:  "certificate",
@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/urfave/cli"
 
 	"github.com/xigang/kongctl/common/client"
 	"github.com/xigang/kongctl/common/tools"
+	"github.com/xigang/kongctl/pkg/plugin/traffic_control"
 )
 
 // https://docs.konghq.com/1.0.x/admin-api/#consumer-object
@@ -22,6 +24,8 @@ import (
 
 const (
 	CONSUMER_RESOURCE_OBJECT = "consumers"
+	//Shorter secrets are masked fully, longer ones keep their last 4 characters.
+	MASK_VISIBLE_MIN_LENGTH = 16
 )
 
 type Consumer struct {
@@ -90,6 +94,27 @@ var ConsumerResourceObjectCommnad = cli.Command{
 			},
 			Action: deleteConsumber,
 		},
+		{
+			Name:      "describe",
+			Usage:     "aggregate the credentials, acl groups and plugins of a consumer",
+			ArgsUsage: "<username>",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "username",
+					Usage: "the consumer username or id",
+				},
+				cli.BoolFlag{
+					Name:  "show_secrets",
+					Usage: "print the credential secrets instead of masking them",
+				},
+			},
+			Action: describeConsumer,
+		},
+		consumerImportCommand,
+		consumerExportCommand,
+		consumerRotateKeyCommand,
+		consumerPurgeRotatedCommand,
+		traffic_control.ConsumerACLCommand,
 	},
 }
 
@@ -200,6 +225,10 @@ func deleteConsumber(c *cli.Context) error {
 		return fmt.Errorf("username and id invalid.")
 	}
 
+	if err := confirmDestructive(c, fmt.Sprintf("delete consumer %s.", resourceName(username, id)), resourceName(username, id), false); err != nil {
+		return err
+	}
+
 	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cannel()
 
@@ -216,3 +245,126 @@ func deleteConsumber(c *cli.Context) error {
 
 	return nil
 }
+
+//consumerCredential describe a kind of credential a consumer can own.
+type consumerCredential struct {
+	//the auth plugin name, also the admin api path under the consumer.
+	plugin string
+	//the field identifying the credential.
+	identifier string
+	//the fields holding secrets.
+	secrets []string
+}
+
+var consumerCredentials = []consumerCredential{
+	{plugin: "basic-auth", identifier: "username", secrets: []string{"password"}},
+	{plugin: "key-auth", identifier: "key", secrets: []string{"key"}},
+	{plugin: "jwt", identifier: "key", secrets: []string{"secret", "rsa_public_key"}},
+	{plugin: "oauth2", identifier: "client_id", secrets: []string{"client_secret"}},
+	{plugin: "hmac-auth", identifier: "username", secrets: []string{"secret"}},
+}
+
+//describeConsumer print the consumer, its credentials of every auth plugin, its acl groups and its plugins.
+func describeConsumer(c *cli.Context) error {
+	username := c.String("username")
+	if username == "" {
+		username = c.Args().First()
+	}
+	if username == "" {
+		return fmt.Errorf("consumer username is empty")
+	}
+	showSecrets := c.Bool("show_secrets")
+
+	consumer := &ConsumerConfig{}
+	if err := getObject(fmt.Sprintf("%s/%s", CONSUMER_RESOURCE_OBJECT, username), consumer); err != nil {
+		return err
+	}
+
+	fmt.Printf("ID:        %s\nUSERNAME:  %s\nCUSTOM_ID: %s\n\n", consumer.ID, consumer.Username, consumer.CustomID)
+
+	var unavailable []string
+
+	fmt.Printf("%-12s\t%-40s\t%-30s\t%-40s\n", "CREDENTIAL", "ID", "IDENTIFIER", "SECRETS")
+	for _, kind := range consumerCredentials {
+		credentials, err := listAllObjects(fmt.Sprintf("%s/%s/%s", CONSUMER_RESOURCE_OBJECT, consumer.ID, kind.plugin), nil)
+		if isNotFound(err) {
+			//the auth plugin is not installed on this kong node.
+			unavailable = append(unavailable, kind.plugin)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, raw := range credentials {
+			credential := map[string]interface{}{}
+			if err := json.Unmarshal(raw, &credential); err != nil {
+				return err
+			}
+
+			identifier := fmt.Sprint(credential[kind.identifier])
+			var secrets []string
+			for _, field := range kind.secrets {
+				value, ok := credential[field].(string)
+				if !ok || value == "" {
+					continue
+				}
+				if !showSecrets {
+					value = maskSecret(value)
+				}
+				if field == kind.identifier {
+					identifier = value
+					continue
+				}
+				secrets = append(secrets, fmt.Sprintf("%s=%s", field, value))
+			}
+
+			fmt.Printf("%-12s\t%-40s\t%-30s\t%-40s\n", kind.plugin, credential["id"], identifier, strings.Join(secrets, " "))
+		}
+	}
+
+	groups, err := listAllObjects(fmt.Sprintf("%s/%s/acls", CONSUMER_RESOURCE_OBJECT, consumer.ID), nil)
+	switch {
+	case isNotFound(err):
+		unavailable = append(unavailable, "acl")
+	case err != nil:
+		return err
+	default:
+		names := make([]string, 0, len(groups))
+		for _, raw := range groups {
+			g := traffic_control.ACLGroup{}
+			if err := json.Unmarshal(raw, &g); err != nil {
+				return err
+			}
+			names = append(names, g.Group)
+		}
+		fmt.Printf("\nACL GROUPS: %s\n", strings.Join(names, ", "))
+	}
+
+	plugins, err := fetchAllPlugins("")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%-40s\t%-20s\t%-50s\t%-10s\n", "PLUGIN_ID", "NAME", "SCOPE", "ENABLED")
+	for _, p := range plugins {
+		if p.ConsumerID.ID != consumer.ID {
+			continue
+		}
+		fmt.Printf("%-40s\t%-20s\t%-50s\t%-10t\n", p.ID, p.Name, pluginScope(p), p.Enabled)
+	}
+
+	if len(unavailable) > 0 {
+		fmt.Printf("\nnot installed on this kong node: %s\n", strings.Join(unavailable, ", "))
+	}
+
+	return nil
+}
+
+//maskSecret hide a secret, a long secret keeps its last 4 characters so it can still be told apart.
+func maskSecret(secret string) string {
+	if len(secret) < MASK_VISIBLE_MIN_LENGTH {
+		return "********"
+	}
+	return "********" + secret[len(secret)-4:]
+}
@@ -0,0 +1,628 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/common/client"
+	"github.com/xigang/kongctl/pkg/plugin/traffic_control"
+)
+
+// Consumers are imported and exported as csv or json lines. A csv file has the columns
+//
+//	username,custom_id,groups,credentials
+//
+// where groups is a ; separated list, and credentials a ; separated list of type:field[:field],
+// e.g. basic-auth:alice:secret;key-auth:0123456789;jwt:issuer:secret:RS256:<pem>.
+// A json lines file holds one consumerRecord per line.
+//
+// An import creates the missing consumers, and adds the missing groups and credentials to the existing ones,
+// so it can be run again after a failure. Kong stores basic-auth passwords hashed, so an export leaves
+// basic-auth credentials out unless --basic-auth, and then their password must be filled in before importing.
+
+const (
+	FORMAT_CSV   = "csv"
+	FORMAT_JSONL = "jsonl"
+)
+
+var consumerCSVHeader = []string{"username", "custom_id", "groups", "credentials"}
+
+//the fields of each credential type, in the order they are written in csv.
+//algorithm and rsa_public_key of jwt follow the key and secret, so a jwt:key:secret file still reads.
+var consumerRecordCredentialFields = map[string][]string{
+	"basic-auth": {"username", "password"},
+	"key-auth":   {"key"},
+	"hmac-auth":  {"username", "secret"},
+	"jwt":        {"key", "secret", "algorithm", "rsa_public_key"},
+}
+
+//consumerRecord is a consumer with its acl groups and credentials, as imported and exported.
+type consumerRecord struct {
+	Username string   `json:"username"`
+	CustomID string   `json:"custom_id,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+	//Every credential holds its type and the fields of the type.
+	Credentials []map[string]string `json:"credentials,omitempty"`
+}
+
+var consumerImportCommand = cli.Command{
+	Name:  "import",
+	Usage: "create consumers, their acl groups and credentials from a csv or json lines file",
+	Flags: []cli.Flag{
+		cli.StringFlag{Name: "file", Usage: "the csv or json lines file"},
+		cli.StringFlag{Name: "format", Usage: "csv or jsonl, guessed from the file extension when empty"},
+		cli.IntFlag{Name: "concurrency", Value: 4, Usage: "the number of consumers imported concurrently"},
+	},
+	Action: importConsumers,
+}
+
+var consumerExportCommand = cli.Command{
+	Name:  "export",
+	Usage: "export consumers, their acl groups and credentials to a csv or json lines file",
+	Flags: []cli.Flag{
+		cli.StringFlag{Name: "file", Usage: "the csv or json lines file, stdout when empty"},
+		cli.StringFlag{Name: "format", Usage: "csv or jsonl, guessed from the file extension when empty"},
+		cli.BoolFlag{Name: "basic-auth", Usage: "export the basic-auth credentials without their password, which must be filled in before importing"},
+	},
+	Action: exportConsumers,
+}
+
+//importResult is the outcome of importing a row.
+type importResult struct {
+	row      int
+	username string
+	result   string
+	message  string
+}
+
+//importConsumers create or complete the consumers of a file with bounded concurrency.
+func importConsumers(c *cli.Context) error {
+	file := c.String("file")
+	if file == "" {
+		return fmt.Errorf("the import file is not allow empty")
+	}
+
+	format, err := recordFormat(file, c.String("format"))
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	records, err := readConsumerRecords(f, format)
+	if err != nil {
+		return err
+	}
+
+	concurrency := c.Int("concurrency")
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]importResult, len(records))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, record := range records {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, record consumerRecord) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			results[i] = importConsumer(record)
+			results[i].row = i + 1
+		}(i, record)
+	}
+	wg.Wait()
+
+	counts := map[string]int{}
+	fmt.Printf("%-6s\t%-30s\t%-10s\t%-40s\n", "ROW", "USERNAME", "RESULT", "MESSAGE")
+	for _, r := range results {
+		counts[r.result]++
+		fmt.Printf("%-6d\t%-30s\t%-10s\t%-40s\n", r.row, r.username, r.result, r.message)
+	}
+	fmt.Printf("\ncreated: %d updated: %d skipped: %d failed: %d\n", counts["created"], counts["updated"], counts["skipped"], counts["failed"])
+
+	if counts["failed"] > 0 {
+		return fmt.Errorf("failed to import %d of %d consumers", counts["failed"], len(records))
+	}
+	return nil
+}
+
+//importConsumer create a consumer unless it exists, then add the acl groups and credentials it misses.
+func importConsumer(record consumerRecord) importResult {
+	r := importResult{username: record.Username}
+
+	failed := func(err error) importResult {
+		r.result, r.message = "failed", err.Error()
+		return r
+	}
+
+	if record.Username == "" && record.CustomID == "" {
+		return failed(fmt.Errorf("username and custom_id are empty"))
+	}
+
+	//a consumer is not created for a record kong would reject a credential of.
+	for _, credential := range record.Credentials {
+		if err := validateRecordCredential(credential); err != nil {
+			return failed(err)
+		}
+	}
+
+	consumer, err := findConsumer(record)
+	if err != nil {
+		return failed(err)
+	}
+
+	created := consumer == nil
+	if created {
+		//empty fields are left out, kong rejects a duplicated empty custom_id.
+		fields := map[string]string{}
+		if record.Username != "" {
+			fields["username"] = record.Username
+		}
+		if record.CustomID != "" {
+			fields["custom_id"] = record.CustomID
+		}
+
+		consumer = &ConsumerConfig{}
+		if err := postObject(CONSUMER_RESOURCE_OBJECT, fields, consumer); err != nil {
+			return failed(err)
+		}
+	}
+
+	groups, credentials, err := missingConsumerRecord(consumer.ID, record, created)
+	if err != nil {
+		return failed(fmt.Errorf("consumer %s: %v", consumer.ID, err))
+	}
+
+	for _, group := range groups {
+		if err := postObject(fmt.Sprintf("%s/%s/acls", CONSUMER_RESOURCE_OBJECT, consumer.ID), &traffic_control.ACLGroup{Group: group}, nil); err != nil {
+			return failed(fmt.Errorf("consumer %s: failed to add group %s: %v", consumer.ID, group, err))
+		}
+	}
+
+	for _, credential := range credentials {
+		kind := credential["type"]
+		fields := map[string]string{}
+		for k, v := range credential {
+			if k != "type" {
+				fields[k] = v
+			}
+		}
+
+		if err := postObject(fmt.Sprintf("%s/%s/%s", CONSUMER_RESOURCE_OBJECT, consumer.ID, kind), fields, nil); err != nil {
+			return failed(fmt.Errorf("consumer %s: failed to add %s credential: %v", consumer.ID, kind, err))
+		}
+	}
+
+	switch {
+	case created:
+		r.result = "created"
+	case len(groups)+len(credentials) > 0:
+		r.result = "updated"
+	default:
+		r.result, r.message = "skipped", "consumer already exists"
+		return r
+	}
+
+	r.message = fmt.Sprintf("%s, %d group(s), %d credential(s) added", consumer.ID, len(groups), len(credentials))
+	return r
+}
+
+//findConsumer return the consumer of the username, or of the custom_id when the username is empty, nil if none exists.
+func findConsumer(record consumerRecord) (*ConsumerConfig, error) {
+	if record.Username != "" {
+		consumer := &ConsumerConfig{}
+		err := getObject(fmt.Sprintf("%s/%s", CONSUMER_RESOURCE_OBJECT, record.Username), consumer)
+		if isNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return consumer, nil
+	}
+
+	//the consumer path matches an id or a username, never a custom_id.
+	q := url.Values{}
+	q.Set("custom_id", record.CustomID)
+	objects, err := listAllObjects(CONSUMER_RESOURCE_OBJECT, q)
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, nil
+	}
+
+	consumer := &ConsumerConfig{}
+	if err := json.Unmarshal(objects[0], consumer); err != nil {
+		return nil, err
+	}
+	return consumer, nil
+}
+
+//missingConsumerRecord return the groups and credentials of the record the consumer does not have yet.
+//Credentials are compared by their first field, e.g. the key of a key-auth credential.
+func missingConsumerRecord(consumerID string, record consumerRecord, created bool) ([]string, []map[string]string, error) {
+	if created {
+		return record.Groups, record.Credentials, nil
+	}
+
+	var groups []string
+	if len(record.Groups) > 0 {
+		existing, err := traffic_control.GetConsumerACLGroups(consumerID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		has := map[string]bool{}
+		for _, g := range existing {
+			has[g.Group] = true
+		}
+		for _, group := range record.Groups {
+			if !has[group] {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	identifiers := map[string]map[string]bool{}
+	var credentials []map[string]string
+	for _, credential := range record.Credentials {
+		kind := credential["type"]
+		field := consumerRecordCredentialFields[kind][0]
+
+		if identifiers[kind] == nil {
+			objects, err := listAllObjects(fmt.Sprintf("%s/%s/%s", CONSUMER_RESOURCE_OBJECT, consumerID, kind), nil)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to list the %s credentials: %v", kind, err)
+			}
+
+			identifiers[kind] = map[string]bool{}
+			for _, raw := range objects {
+				object := map[string]interface{}{}
+				if err := json.Unmarshal(raw, &object); err != nil {
+					return nil, nil, err
+				}
+				if v, ok := object[field].(string); ok {
+					identifiers[kind][v] = true
+				}
+			}
+		}
+
+		if !identifiers[kind][credential[field]] {
+			credentials = append(credentials, credential)
+		}
+	}
+
+	return groups, credentials, nil
+}
+
+//validateRecordCredential check a credential has every field kong requires.
+func validateRecordCredential(credential map[string]string) error {
+	kind := credential["type"]
+	fields, ok := consumerRecordCredentialFields[kind]
+	if !ok {
+		return fmt.Errorf("credential type %s is invalid, available types are basic-auth, key-auth, hmac-auth, jwt", kind)
+	}
+
+	switch kind {
+	case "basic-auth":
+		//an exported basic-auth credential has no password.
+		if credential["username"] == "" || credential["password"] == "" {
+			return fmt.Errorf("basic-auth credential %s needs a username and a password", credential["username"])
+		}
+	case "jwt":
+		if credential["key"] == "" {
+			return fmt.Errorf("jwt credential needs a key")
+		}
+		//kong verifies RS256 and ES256 tokens with the public key, not the secret.
+		switch credential["algorithm"] {
+		case "RS256", "ES256":
+			if credential["rsa_public_key"] == "" {
+				return fmt.Errorf("jwt credential %s with algorithm %s needs a rsa_public_key", credential["key"], credential["algorithm"])
+			}
+		}
+	default:
+		if credential[fields[0]] == "" {
+			return fmt.Errorf("%s credential needs a %s", kind, fields[0])
+		}
+	}
+	return nil
+}
+
+//postObject create a admin api object and decode the created object into v when it is not nil.
+func postObject(requestURL string, obj interface{}, v interface{}) error {
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	serverResponse, err := client.GatewayClient.Post(ctx, requestURL, nil, obj, nil)
+	if err != nil {
+		return err
+	}
+	defer serverResponse.Body.Close()
+
+	if v == nil {
+		return nil
+	}
+	return json.NewDecoder(serverResponse.Body).Decode(v)
+}
+
+//exportConsumers write every consumer with its acl groups and credentials.
+func exportConsumers(c *cli.Context) error {
+	file := c.String("file")
+
+	format, err := recordFormat(file, c.String("format"))
+	if err != nil {
+		return err
+	}
+
+	consumers, err := fetchAllConsumers()
+	if err != nil {
+		return err
+	}
+
+	records := make([]consumerRecord, 0, len(consumers))
+	skipped := 0
+	for _, consumer := range consumers {
+		record, n, err := exportConsumer(consumer, c.Bool("basic-auth"))
+		if err != nil {
+			return fmt.Errorf("failed to export consumer %s: %v", consumer.ID, err)
+		}
+		records = append(records, record)
+		skipped += n
+	}
+
+	if skipped > 0 {
+		fmt.Fprintf(os.Stderr, "%d basic-auth credentials are not exported, their password is hashed by kong, use --basic-auth to export them without password\n", skipped)
+	}
+
+	var w io.Writer = os.Stdout
+	if file != "" {
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := writeConsumerRecords(w, format, records); err != nil {
+		return err
+	}
+
+	if file != "" {
+		fmt.Printf("export %d consumers to %s\n", len(records), file)
+	}
+	return nil
+}
+
+//exportConsumer collect the acl groups and credentials of a consumer. Basic-auth passwords are stored hashed by kong,
+//so basic-auth credentials are exported without a password with basicAuth, otherwise they are skipped and counted.
+func exportConsumer(consumer ConsumerConfig, basicAuth bool) (consumerRecord, int, error) {
+	record := consumerRecord{Username: consumer.Username, CustomID: consumer.CustomID}
+	skipped := 0
+
+	groups, err := listAllObjects(fmt.Sprintf("%s/%s/acls", CONSUMER_RESOURCE_OBJECT, consumer.ID), nil)
+	if err != nil && !isNotFound(err) {
+		return record, skipped, err
+	}
+	//the acl plugin is not installed on this kong node when not found.
+	for _, raw := range groups {
+		g := traffic_control.ACLGroup{}
+		if err := json.Unmarshal(raw, &g); err != nil {
+			return record, skipped, err
+		}
+		record.Groups = append(record.Groups, g.Group)
+	}
+
+	kinds := make([]string, 0, len(consumerRecordCredentialFields))
+	for kind := range consumerRecordCredentialFields {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	for _, kind := range kinds {
+		credentials, err := listAllObjects(fmt.Sprintf("%s/%s/%s", CONSUMER_RESOURCE_OBJECT, consumer.ID, kind), nil)
+		if isNotFound(err) {
+			//the auth plugin is not installed on this kong node.
+			continue
+		}
+		if err != nil {
+			return record, skipped, err
+		}
+
+		if kind == "basic-auth" && !basicAuth {
+			skipped += len(credentials)
+			continue
+		}
+
+		for _, raw := range credentials {
+			object := map[string]interface{}{}
+			if err := json.Unmarshal(raw, &object); err != nil {
+				return record, skipped, err
+			}
+
+			credential := map[string]string{"type": kind}
+			for _, field := range consumerRecordCredentialFields[kind] {
+				if kind == "basic-auth" && field == "password" {
+					continue
+				}
+				if v, ok := object[field].(string); ok {
+					credential[field] = v
+				}
+			}
+			record.Credentials = append(record.Credentials, credential)
+		}
+	}
+
+	return record, skipped, nil
+}
+
+//recordFormat return the format of a import/export file.
+func recordFormat(file, format string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".csv":
+			format = FORMAT_CSV
+		case ".jsonl", ".ndjson", ".json":
+			format = FORMAT_JSONL
+		default:
+			return "", fmt.Errorf("can not guess the format of %q, use --format csv or jsonl", file)
+		}
+	}
+
+	if format != FORMAT_CSV && format != FORMAT_JSONL {
+		return "", fmt.Errorf("format %s is invalid, available formats are csv, jsonl", format)
+	}
+	return format, nil
+}
+
+func readConsumerRecords(r io.Reader, format string) ([]consumerRecord, error) {
+	var records []consumerRecord
+
+	if format == FORMAT_JSONL {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+
+			record := consumerRecord{}
+			if err := json.Unmarshal([]byte(text), &record); err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+			records = append(records, record)
+		}
+		return records, scanner.Err()
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, row := range rows {
+		if i == 0 && len(row) > 0 && strings.EqualFold(strings.TrimSpace(row[0]), "username") {
+			continue
+		}
+
+		for len(row) < len(consumerCSVHeader) {
+			row = append(row, "")
+		}
+
+		record := consumerRecord{
+			Username: strings.TrimSpace(row[0]),
+			CustomID: strings.TrimSpace(row[1]),
+			Groups:   splitRecordList(row[2]),
+		}
+
+		for _, raw := range splitRecordList(row[3]) {
+			credential, err := parseRecordCredential(raw)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: %v", i+1, err)
+			}
+			record.Credentials = append(record.Credentials, credential)
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func writeConsumerRecords(w io.Writer, format string, records []consumerRecord) error {
+	if format == FORMAT_JSONL {
+		encoder := json.NewEncoder(w)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(consumerCSVHeader); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		credentials := make([]string, 0, len(record.Credentials))
+		for _, credential := range record.Credentials {
+			credentials = append(credentials, formatRecordCredential(credential))
+		}
+
+		row := []string{record.Username, record.CustomID, strings.Join(record.Groups, ";"), strings.Join(credentials, ";")}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func splitRecordList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ";") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+//parseRecordCredential parse a credential written as type:field[:field].
+func parseRecordCredential(value string) (map[string]string, error) {
+	kind := strings.SplitN(value, ":", 2)[0]
+
+	fields, ok := consumerRecordCredentialFields[kind]
+	if !ok {
+		return nil, fmt.Errorf("credential type %s is invalid, available types are basic-auth, key-auth, hmac-auth, jwt", kind)
+	}
+
+	//the last field keeps any colon, e.g. in a password.
+	parts := strings.SplitN(value, ":", len(fields)+1)
+
+	credential := map[string]string{"type": kind}
+	for i, v := range parts[1:] {
+		if v != "" {
+			credential[fields[i]] = v
+		}
+	}
+	return credential, nil
+}
+
+func formatRecordCredential(credential map[string]string) string {
+	kind := credential["type"]
+
+	parts := []string{kind}
+	for _, field := range consumerRecordCredentialFields[kind] {
+		parts = append(parts, credential[field])
+	}
+	return strings.TrimRight(strings.Join(parts, ":"), ":")
+}
@@ -0,0 +1,206 @@
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRecordCredential(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{value: "key-auth:0123456789", want: map[string]string{"type": "key-auth", "key": "0123456789"}},
+		{value: "basic-auth:alice:s3:cr:et", want: map[string]string{"type": "basic-auth", "username": "alice", "password": "s3:cr:et"}},
+		{value: "basic-auth:alice", want: map[string]string{"type": "basic-auth", "username": "alice"}},
+		{value: "jwt:iss1:s1", want: map[string]string{"type": "jwt", "key": "iss1", "secret": "s1"}},
+		{value: "jwt:iss1:s1:ES256:pem", want: map[string]string{"type": "jwt", "key": "iss1", "secret": "s1", "algorithm": "ES256", "rsa_public_key": "pem"}},
+		{value: "oauth2:client", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		got, err := parseRecordCredential(tc.value)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tc.value, err, tc.wantErr)
+			continue
+		}
+		if !tc.wantErr && !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.value, got, tc.want)
+		}
+		if !tc.wantErr && formatRecordCredential(got) != tc.value {
+			t.Errorf("%s: formatted as %s", tc.value, formatRecordCredential(got))
+		}
+	}
+}
+
+func TestRecordFormat(t *testing.T) {
+	cases := []struct {
+		file, format string
+		want         string
+		wantErr      bool
+	}{
+		{file: "consumers.csv", want: FORMAT_CSV},
+		{file: "consumers.JSONL", want: FORMAT_JSONL},
+		{file: "consumers.ndjson", want: FORMAT_JSONL},
+		{file: "consumers.txt", format: "csv", want: FORMAT_CSV},
+		{file: "consumers.txt", wantErr: true},
+		{file: "", format: "jsonl", want: FORMAT_JSONL},
+		{file: "", format: "xml", wantErr: true},
+		{file: "", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		got, err := recordFormat(tc.file, tc.format)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("recordFormat(%q, %q): got %q, %v", tc.file, tc.format, got, err)
+		}
+	}
+}
+
+func TestConsumerRecordsRoundTrip(t *testing.T) {
+	records := []consumerRecord{
+		{Username: "alice", CustomID: "42", Groups: []string{"admin", "ops"}, Credentials: []map[string]string{
+			{"type": "key-auth", "key": "k1"},
+			{"type": "basic-auth", "username": "alice", "password": "p:w"},
+			{"type": "jwt", "key": "iss1", "secret": "s1", "algorithm": "RS256", "rsa_public_key": "-----BEGIN PUBLIC KEY-----\nMIIBIjAN\n-----END PUBLIC KEY-----"},
+		}},
+		{Username: "bob"},
+	}
+
+	for _, format := range []string{FORMAT_CSV, FORMAT_JSONL} {
+		buf := &bytes.Buffer{}
+		if err := writeConsumerRecords(buf, format, records); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := readConsumerRecords(buf, format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if !reflect.DeepEqual(got, records) {
+			t.Errorf("%s: got %+v, want %+v", format, got, records)
+		}
+	}
+}
+
+func TestReadConsumerRecordsCSV(t *testing.T) {
+	input := "username,custom_id,groups,credentials\n" +
+		"alice,,admin; ops ,key-auth:k1\n" +
+		",42\n"
+
+	got, err := readConsumerRecords(strings.NewReader(input), FORMAT_CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []consumerRecord{
+		{Username: "alice", Groups: []string{"admin", "ops"}, Credentials: []map[string]string{{"type": "key-auth", "key": "k1"}}},
+		{CustomID: "42"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if _, err := readConsumerRecords(strings.NewReader("alice,,,ldap:x\n"), FORMAT_CSV); err == nil {
+		t.Errorf("invalid credential type: got no error")
+	}
+}
+
+func TestImportConsumer(t *testing.T) {
+	cases := []struct {
+		name      string
+		record    consumerRecord
+		responses map[string]fakeResponse
+		want      string
+	}{
+		{
+			name:   "new consumer",
+			record: consumerRecord{Username: "alice", Groups: []string{"admin"}, Credentials: []map[string]string{{"type": "key-auth", "key": "k1"}}},
+			responses: map[string]fakeResponse{
+				"POST /consumers":             {http.StatusCreated, `{"id":"c1","username":"alice"}`},
+				"POST /consumers/c1/acls":     {http.StatusCreated, `{}`},
+				"POST /consumers/c1/key-auth": {http.StatusCreated, `{}`},
+			},
+			want: "created",
+		},
+		{
+			name:   "existing consumer found by custom_id misses a credential",
+			record: consumerRecord{CustomID: "42", Groups: []string{"admin"}, Credentials: []map[string]string{{"type": "key-auth", "key": "k2"}}},
+			responses: map[string]fakeResponse{
+				"GET /consumers":              {http.StatusOK, `{"data":[{"id":"c1","custom_id":"42"}]}`},
+				"GET /consumers/c1/acls":      {http.StatusOK, `{"data":[{"id":"a1","group":"admin"}]}`},
+				"GET /consumers/c1/key-auth":  {http.StatusOK, `{"data":[{"id":"k1","key":"k1"}]}`},
+				"POST /consumers/c1/key-auth": {http.StatusCreated, `{}`},
+			},
+			want: "updated",
+		},
+		{
+			name:   "existing consumer has everything",
+			record: consumerRecord{Username: "alice", Credentials: []map[string]string{{"type": "key-auth", "key": "k1"}}},
+			responses: map[string]fakeResponse{
+				"GET /consumers/alice":       {http.StatusOK, `{"id":"c1","username":"alice"}`},
+				"GET /consumers/c1/key-auth": {http.StatusOK, `{"data":[{"id":"k1","key":"k1"}]}`},
+			},
+			want: "skipped",
+		},
+		{
+			name:   "basic-auth without password is rejected before the consumer is created",
+			record: consumerRecord{Username: "alice", Credentials: []map[string]string{{"type": "basic-auth", "username": "alice"}}},
+			responses: map[string]fakeResponse{
+				"POST /consumers": {http.StatusInternalServerError, `{}`},
+			},
+			want: "failed",
+		},
+		{
+			name:   "RS256 jwt without rsa_public_key is rejected before the consumer is created",
+			record: consumerRecord{Username: "alice", Credentials: []map[string]string{{"type": "jwt", "key": "iss1", "algorithm": "RS256"}}},
+			responses: map[string]fakeResponse{
+				"POST /consumers": {http.StatusInternalServerError, `{}`},
+			},
+			want: "failed",
+		},
+	}
+
+	for _, tc := range cases {
+		restore := fakeKong(t, tc.responses)
+		got := importConsumer(tc.record)
+		restore()
+
+		if got.result != tc.want {
+			t.Errorf("%s: got %s (%s), want %s", tc.name, got.result, got.message, tc.want)
+		}
+	}
+}
+
+func TestExportConsumer(t *testing.T) {
+	restore := fakeKong(t, map[string]fakeResponse{
+		"/consumers/c1/acls": {http.StatusOK, `{"data":[{"id":"a1","group":"admin"}]}`},
+		"/consumers/c1/jwt":  {http.StatusOK, `{"data":[{"id":"j1","key":"iss1","secret":"s1","algorithm":"RS256","rsa_public_key":"pem"}]}`},
+	})
+	got, _, err := exportConsumer(ConsumerConfig{ID: "c1", Username: "alice"}, false)
+	restore()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := consumerRecord{Username: "alice", Groups: []string{"admin"}, Credentials: []map[string]string{
+		{"type": "jwt", "key": "iss1", "secret": "s1", "algorithm": "RS256", "rsa_public_key": "pem"},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	//only a missing acl plugin is tolerated, a failing kong is not exported as a consumer without groups.
+	restore = fakeKong(t, map[string]fakeResponse{
+		"/consumers/c1/acls": {http.StatusInternalServerError, `{"message":"An unexpected error occurred"}`},
+	})
+	_, _, err = exportConsumer(ConsumerConfig{ID: "c1", Username: "alice"}, false)
+	restore()
+	if err == nil {
+		t.Errorf("acl groups failed with 500: got no error")
+	}
+}
@@ -0,0 +1,239 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/common/client"
+	"github.com/xigang/kongctl/common/tools"
+	"github.com/xigang/kongctl/pkg/plugin/authentication"
+)
+
+// A key rotation creates a new credential and keeps the old ones so clients can switch over.
+// With --delete-old-after the old credentials are deleted once the grace period is over, by a later
+// `consumer purge-rotated`, e.g. from cron. Every rotation is recorded in the rotations state file,
+// with the kong node it was made on, and is only purged on that node.
+
+const (
+	ROTATIONS_STATE_FILE = "rotations.json"
+)
+
+//credentialRotation is a rotation recorded in the local state.
+type credentialRotation struct {
+	//The admin api address of the kong node, and the kongctl context if any.
+	Gateway    string    `json:"gateway"`
+	Context    string    `json:"context,omitempty"`
+	ConsumerID string    `json:"consumer_id"`
+	Username   string    `json:"username"`
+	Type       string    `json:"type"`
+	NewID      string    `json:"new_id"`
+	OldIDs     []string  `json:"old_ids"`
+	RotatedAt  time.Time `json:"rotated_at"`
+	//DeleteAfter is nil when the old credentials are kept.
+	DeleteAfter *time.Time `json:"delete_after,omitempty"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+var consumerRotateKeyCommand = cli.Command{
+	Name:  "rotate-key",
+	Usage: "create a new key-auth or basic-auth credential for a consumer and print it once",
+	Flags: []cli.Flag{
+		cli.StringFlag{Name: "username", Usage: "the consumer username or id"},
+		cli.StringFlag{Name: "type", Value: authentication.PLUGIN_KEY_AUTH, Usage: "the credential type: key-auth, basic-auth"},
+		cli.StringFlag{Name: "credential_username", Usage: "the basic-auth username of the new credential, by default <username>-<date>"},
+		cli.DurationFlag{Name: "delete-old-after", Usage: "delete the old credentials after this grace period, e.g. 72h, 0s deletes them now"},
+	},
+	Action: rotateConsumerKey,
+}
+
+var consumerPurgeRotatedCommand = cli.Command{
+	Name:   "purge-rotated",
+	Usage:  "delete the old credentials of the rotations whose grace period is over",
+	Action: purgeRotatedCredentials,
+}
+
+//rotateConsumerKey create a new credential, then record the old ones and their deletion time.
+func rotateConsumerKey(c *cli.Context) error {
+	username := c.String("username")
+	kind := c.String("type")
+
+	if username == "" {
+		return fmt.Errorf("consumer username is not allow empty")
+	}
+
+	if kind != authentication.PLUGIN_KEY_AUTH && kind != authentication.PLUGIN_BASIC_AUTH {
+		return fmt.Errorf("type %s is invalid, available types are key-auth, basic-auth", kind)
+	}
+
+	consumer := &ConsumerConfig{}
+	if err := getObject(fmt.Sprintf("%s/%s", CONSUMER_RESOURCE_OBJECT, username), consumer); err != nil {
+		return err
+	}
+
+	old, err := listAllObjects(fmt.Sprintf("%s/%s/%s", CONSUMER_RESOURCE_OBJECT, consumer.ID, kind), nil)
+	if err != nil {
+		return err
+	}
+
+	oldIDs := make([]string, 0, len(old))
+	for _, raw := range old {
+		credential := struct {
+			ID string `json:"id"`
+		}{}
+		if err := json.Unmarshal(raw, &credential); err != nil {
+			return err
+		}
+		oldIDs = append(oldIDs, credential.ID)
+	}
+
+	var body []byte
+	var secret string
+	switch kind {
+	case authentication.PLUGIN_KEY_AUTH:
+		if secret, err = authentication.GenerateSecret(16); err != nil {
+			return err
+		}
+		body, err = authentication.CreateKeyAuthCredential(consumer.ID, &authentication.KeyAuthCredential{Key: secret})
+	case authentication.PLUGIN_BASIC_AUTH:
+		//basic-auth usernames are unique, the new credential can not reuse the old username during the grace period.
+		credentialUsername := c.String("credential_username")
+		if credentialUsername == "" {
+			credentialUsername = fmt.Sprintf("%s-%s", consumer.Username, time.Now().Format("20060102150405"))
+		}
+		if secret, err = authentication.GenerateSecret(16); err != nil {
+			return err
+		}
+		body, err = authentication.CreateBasicAuthCredential(consumer.ID, &authentication.BasicAuthCredential{Username: credentialUsername, Password: secret})
+		secret = fmt.Sprintf("%s:%s", credentialUsername, secret)
+	}
+	if err != nil {
+		return err
+	}
+
+	created := struct {
+		ID string `json:"id"`
+	}{}
+	if err := json.Unmarshal(body, &created); err != nil {
+		return err
+	}
+
+	rotation := credentialRotation{
+		Gateway:    client.GatewayClient.Host(),
+		Context:    activeContext,
+		ConsumerID: consumer.ID,
+		Username:   consumer.Username,
+		Type:       kind,
+		NewID:      created.ID,
+		OldIDs:     oldIDs,
+		RotatedAt:  time.Now().UTC(),
+	}
+
+	if c.IsSet("delete-old-after") {
+		deleteAfter := rotation.RotatedAt.Add(c.Duration("delete-old-after"))
+		rotation.DeleteAfter = &deleteAfter
+	}
+
+	fmt.Printf("new %s credential %s for consumer %s\n", kind, created.ID, consumer.Username)
+	fmt.Printf("\n\t%s\n\nthis secret is printed only once, store it now.\n", secret)
+
+	//0s deletes the old credentials of this rotation only, a failed one is left to purge-rotated.
+	var failed []string
+	if rotation.DeleteAfter != nil && c.Duration("delete-old-after") <= 0 && len(oldIDs) > 0 {
+		failed = deleteRotatedCredentials(&rotation, rotation.RotatedAt)
+	}
+
+	var rotations []credentialRotation
+	err = tools.UpdateState(ROTATIONS_STATE_FILE, &rotations, func() error {
+		rotations = append(rotations, rotation)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("credential %s created but the rotation is not recorded: %v", created.ID, err)
+	}
+
+	switch {
+	case len(oldIDs) == 0:
+	case rotation.DeleteAfter == nil:
+		fmt.Printf("old credentials kept: %s\n", strings.Join(oldIDs, ", "))
+	case len(failed) > 0:
+		return fmt.Errorf("failed to delete credentials: %s, run purge-rotated to retry", strings.Join(failed, "; "))
+	case rotation.DeletedAt != nil:
+	default:
+		fmt.Printf("old credentials %s will be deleted by purge-rotated after %s\n", strings.Join(oldIDs, ", "), rotation.DeleteAfter.Local().Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+func purgeRotatedCredentials(c *cli.Context) error {
+	if err := confirmDestructive(c, "delete the old credentials of the rotations whose grace period is over.", confirmWord("purge"), false); err != nil {
+		return err
+	}
+	return purgeRotations()
+}
+
+//deleteRotatedCredentials delete the old credentials of a rotation and mark it deleted when none failed,
+//it returns the credentials failed to delete.
+func deleteRotatedCredentials(r *credentialRotation, now time.Time) []string {
+	var failed []string
+	for _, id := range r.OldIDs {
+		//the rotation was made on this kong node, a credential it does not know is gone.
+		found, err := authentication.DeleteCredential(r.ConsumerID, r.Type, id)
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s (%v)", id, err))
+			continue
+		}
+		if found {
+			fmt.Printf("delete %s credential %s of consumer %s\n", r.Type, id, r.Username)
+		} else {
+			fmt.Printf("%s credential %s of consumer %s is already deleted\n", r.Type, id, r.Username)
+		}
+	}
+
+	if len(failed) == 0 {
+		deletedAt := now
+		r.DeletedAt = &deletedAt
+	}
+	return failed
+}
+
+//purgeRotations delete the old credentials of every rotation of this kong node whose grace period is over.
+func purgeRotations() error {
+	var rotations []credentialRotation
+	var failed []string
+	gateway := client.GatewayClient.Host()
+	now := time.Now().UTC()
+
+	err := tools.UpdateState(ROTATIONS_STATE_FILE, &rotations, func() error {
+		other := 0
+		for i := range rotations {
+			r := &rotations[i]
+			if r.DeleteAfter == nil || r.DeletedAt != nil || now.Before(*r.DeleteAfter) {
+				continue
+			}
+			if r.Gateway != gateway {
+				other++
+				continue
+			}
+
+			failed = append(failed, deleteRotatedCredentials(r, now)...)
+		}
+
+		if other > 0 {
+			fmt.Printf("%d rotations of other kong nodes are not purged, run purge-rotated against their node.\n", other)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to delete credentials: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xigang/kongctl/common/client"
+	"github.com/xigang/kongctl/common/tools"
+)
+
+func TestPurgeRotations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kongctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("KONGCTL_HOME", os.Getenv("KONGCTL_HOME"))
+	os.Setenv("KONGCTL_HOME", dir)
+
+	defer fakeKong(t, map[string]fakeResponse{
+		"DELETE /consumers/c1/key-auth/k1": {http.StatusNoContent, ""},
+		"DELETE /consumers/c3/key-auth/k3": {http.StatusInternalServerError, `{"message":"An unexpected error occurred"}`},
+	})()
+
+	past := time.Now().UTC().Add(-time.Hour)
+	gateway := client.GatewayClient.Host()
+	rotations := []credentialRotation{
+		{Gateway: gateway, ConsumerID: "c1", Type: "key-auth", OldIDs: []string{"k1"}, DeleteAfter: &past},
+		//the credential is deleted already, on this node.
+		{Gateway: gateway, ConsumerID: "c2", Type: "key-auth", OldIDs: []string{"k2"}, DeleteAfter: &past},
+		{Gateway: gateway, ConsumerID: "c3", Type: "key-auth", OldIDs: []string{"k3"}, DeleteAfter: &past},
+		//the credential is unknown to this node, it may still exist on its own.
+		{Gateway: "http://kong-admin.prod:8001", ConsumerID: "c4", Type: "key-auth", OldIDs: []string{"k4"}, DeleteAfter: &past},
+		{Gateway: gateway, ConsumerID: "c5", Type: "key-auth", OldIDs: []string{"k5"}},
+	}
+	if err := tools.WriteState(ROTATIONS_STATE_FILE, rotations); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := purgeRotations(); err == nil {
+		t.Errorf("got no error, want the failure of k3")
+	}
+
+	var got []credentialRotation
+	if err := tools.ReadState(ROTATIONS_STATE_FILE, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{"c1": true, "c2": true, "c3": false, "c4": false, "c5": false}
+	for _, r := range got {
+		if deleted := r.DeletedAt != nil; deleted != want[r.ConsumerID] {
+			t.Errorf("consumer %s: got deleted %v, want %v", r.ConsumerID, deleted, want[r.ConsumerID])
+		}
+	}
+}
+
+func TestRotateConsumerKeyDeleteNow(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kongctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("KONGCTL_HOME", os.Getenv("KONGCTL_HOME"))
+	os.Setenv("KONGCTL_HOME", dir)
+
+	requests, restore := recordKong(t, map[string]fakeResponse{
+		"GET /consumers/alice":             {http.StatusOK, `{"id":"c1","username":"alice"}`},
+		"GET /consumers/c1/key-auth":       {http.StatusOK, `{"data":[{"id":"k1","key":"old"}]}`},
+		"POST /consumers/c1/key-auth":      {http.StatusCreated, `{"id":"k9"}`},
+		"DELETE /consumers/c1/key-auth/k1": {http.StatusNoContent, ""},
+		"DELETE /consumers/c2/key-auth/k2": {http.StatusNoContent, ""},
+	})
+	defer restore()
+
+	//a due rotation of another consumer is left to purge-rotated.
+	past := time.Now().UTC().Add(-time.Hour)
+	gateway := client.GatewayClient.Host()
+	if err := tools.WriteState(ROTATIONS_STATE_FILE, []credentialRotation{
+		{Gateway: gateway, ConsumerID: "c2", Type: "key-auth", OldIDs: []string{"k2"}, DeleteAfter: &past},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newTestContext(t, consumerRotateKeyCommand.Flags, "--username", "alice", "--delete-old-after", "0s")
+	if err := rotateConsumerKey(c); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range *requests {
+		if strings.HasPrefix(r, "DELETE /consumers/c2/") {
+			t.Errorf("got %s, want only the old credentials of the rotation deleted", r)
+		}
+	}
+
+	var got []credentialRotation
+	if err := tools.ReadState(ROTATIONS_STATE_FILE, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{"c1": true, "c2": false}
+	if len(got) != len(want) {
+		t.Fatalf("got %d rotations, want %d", len(got), len(want))
+	}
+	for _, r := range got {
+		if deleted := r.DeletedAt != nil; deleted != want[r.ConsumerID] {
+			t.Errorf("consumer %s: got deleted %v, want %v", r.ConsumerID, deleted, want[r.ConsumerID])
+		}
+	}
+}
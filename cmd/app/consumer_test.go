@@ -0,0 +1,75 @@
+package app
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestMaskSecret(t *testing.T) {
+	cases := []struct {
+		secret, want string
+	}{
+		{"", "********"},
+		{"abc", "********"},
+		{"0123456789", "********"},
+		{"012345678901234", "********"},
+		{"0123456789abcdef", "********cdef"},
+		{"s3cr3t-0123456789-abcdef", "********cdef"},
+	}
+
+	for _, tc := range cases {
+		if got := maskSecret(tc.secret); got != tc.want {
+			t.Errorf("maskSecret(%q): got %q, want %q", tc.secret, got, tc.want)
+		}
+	}
+}
+
+func TestDescribeConsumer(t *testing.T) {
+	flags := []cli.Flag{cli.StringFlag{Name: "username"}, cli.BoolFlag{Name: "show_secrets"}}
+
+	cases := []struct {
+		name      string
+		responses map[string]fakeResponse
+		wantErr   bool
+	}{
+		{
+			name: "the endpoints of plugins not installed are skipped",
+			responses: map[string]fakeResponse{
+				"/consumers/alice":       {http.StatusOK, `{"id":"c1","username":"alice"}`},
+				"/consumers/c1/key-auth": {http.StatusOK, `{"data":[{"id":"k1","key":"0123456789abcdef"}]}`},
+				"/plugins":               {http.StatusOK, `{"data":[]}`},
+			},
+		},
+		{
+			name: "an admin api failure is an error",
+			responses: map[string]fakeResponse{
+				"/consumers/alice":     {http.StatusOK, `{"id":"c1","username":"alice"}`},
+				"/consumers/c1/jwt":    {http.StatusUnauthorized, `{"message":"Unauthorized"}`},
+				"/consumers/c1/oauth2": {http.StatusOK, `{"data":[]}`},
+				"/plugins":             {http.StatusOK, `{"data":[]}`},
+			},
+			wantErr: true,
+		},
+		{
+			name: "an acl failure is an error",
+			responses: map[string]fakeResponse{
+				"/consumers/alice":   {http.StatusOK, `{"id":"c1","username":"alice"}`},
+				"/consumers/c1/acls": {http.StatusInternalServerError, `{"message":"An unexpected error occurred"}`},
+				"/plugins":           {http.StatusOK, `{"data":[]}`},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		restore := fakeKong(t, tc.responses)
+		err := describeConsumer(newTestContext(t, flags, "--username", "alice"))
+		restore()
+
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/pkg/plugin/security"
+)
+
+// The cors simulation evaluates the cors plugin applied to a route locally,
+// and reports which Access-Control-* headers the browser would get.
+
+var CORSCommand = cli.Command{
+	Name:  "cors",
+	Usage: "The kong cors simulation.",
+
+	Subcommands: []cli.Command{
+		{
+			Name:  "simulate",
+			Usage: "evaluate the cors plugin of a route for a browser request",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "origin",
+					Usage: "the Origin header sent by the browser",
+				},
+				cli.StringFlag{
+					Name:  "method",
+					Value: "GET",
+					Usage: "the HTTP method of the request",
+				},
+				cli.StringFlag{
+					Name:  "route",
+					Usage: "the route id",
+				},
+				cli.StringSliceFlag{
+					Name:  "headers",
+					Usage: "the non-simple request headers sent by the browser (Access-Control-Request-Headers)",
+				},
+				cli.BoolFlag{
+					Name:  "preflight",
+					Usage: "always simulate the OPTIONS preflight request, even for simple requests",
+				},
+			},
+			Action: simulateCORS,
+		},
+	},
+}
+
+//simulateCORS print the cors response headers for a request to a route.
+func simulateCORS(c *cli.Context) error {
+	routeID := c.String("route")
+	origin := c.String("origin")
+	method := strings.ToUpper(c.String("method"))
+	headers := c.StringSlice("headers")
+
+	if routeID == "" || origin == "" {
+		return fmt.Errorf("route: %s origin: %s is not allow empty", routeID, origin)
+	}
+
+	route := &RouteConfig{}
+	if err := getObject(fmt.Sprintf("%s/%s", ROUTE_RESOURCE_OBJECT, routeID), route); err != nil {
+		return err
+	}
+
+	plugins, err := fetchAllPlugins(security.PLUGIN_CORS)
+	if err != nil {
+		return err
+	}
+
+	plugin, scope := resolveRoutePlugin(plugins, security.PLUGIN_CORS, route.ID, route.Service.ID)
+	if plugin == nil {
+		fmt.Printf("no cors plugin applies to route %s, the browser gets no Access-Control-* headers.\n", route.ID)
+		return nil
+	}
+
+	cfg := security.CORSConfig{}
+	if err := json.Unmarshal(plugin.Config, &cfg); err != nil {
+		return err
+	}
+
+	fmt.Printf("cors plugin %s applied on %s\n", plugin.ID, scope)
+
+	// browsers only skip the preflight for simple methods without custom headers.
+	simple := (method == "GET" || method == "HEAD" || method == "POST") && len(headers) == 0
+	if c.Bool("preflight") || !simple {
+		printCORSSimulation(fmt.Sprintf("OPTIONS %s (preflight for %s)", route.ID, method), security.SimulateCORS(cfg, origin, method, headers, true))
+	}
+
+	printCORSSimulation(fmt.Sprintf("%s %s", method, route.ID), security.SimulateCORS(cfg, origin, method, headers, false))
+	return nil
+}
+
+func printCORSSimulation(title string, sim security.CORSSimulation) {
+	fmt.Printf("\n%s\n", title)
+
+	fmt.Printf("%-35s\t%-40s\n", "HEADER", "VALUE")
+	for _, h := range sim.Headers {
+		fmt.Printf("%-35s\t%-40s\n", h.Name, h.Value)
+	}
+
+	if sim.Preflight {
+		if sim.Proxied {
+			fmt.Printf("the preflight request is proxied to the upstream service (preflight_continue)\n")
+		} else {
+			fmt.Printf("the preflight request is answered by kong with 204 No Content\n")
+		}
+	}
+
+	if sim.Allowed {
+		fmt.Printf("result: allowed by the browser\n")
+	} else {
+		fmt.Printf("result: blocked by the browser, %s\n", sim.Reason)
+	}
+}
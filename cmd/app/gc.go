@@ -0,0 +1,206 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// The gc finds the entities nothing uses anymore, or that reference deleted entities, and deletes them with --apply.
+// Kong lists targets per upstream only, so the targets of deleted upstreams are only found in a state file,
+// and the consumer credentials are only checked on a live kong.
+
+//gcCandidate is an entity the gc would delete.
+type gcCandidate struct {
+	Kind   string
+	ID     string
+	Name   string
+	Reason string
+	//The admin api path deleting the entity.
+	requestURL string
+}
+
+//the deletion order, the entities referencing others go first.
+var gcKinds = []string{"plugin", "route", "consumer", "target", "upstream", "certificate"}
+
+var GCCommand = cli.Command{
+	Name:  "gc",
+	Usage: "Find the orphan entities and the dangling references, and delete them.",
+	Flags: []cli.Flag{
+		cli.StringFlag{Name: "state", Usage: "a state file to report on, instead of fetching the configuration from kong"},
+		cli.BoolFlag{Name: "apply", Usage: "delete the entities found, after confirmation"},
+		cli.BoolFlag{Name: "yes", Usage: "do not ask for confirmation"},
+	},
+	Action: gc,
+}
+
+func gc(c *cli.Context) error {
+	file := c.String("state")
+	apply := c.Bool("apply")
+
+	if apply && file != "" {
+		return fmt.Errorf("--apply deletes from kong, it can not be used with a state file")
+	}
+
+	state, err := loadGatewayState(file, true)
+	if err != nil {
+		return err
+	}
+
+	candidates := gcOrphans(state)
+
+	if file == "" {
+		consumers, err := gcConsumersWithoutCredentials(state)
+		if err != nil {
+			return err
+		}
+		candidates = append(candidates, consumers...)
+	}
+
+	if len(candidates) == 0 {
+		fmt.Println("nothing to collect.")
+		return nil
+	}
+
+	fmt.Printf("%-12s\t%-40s\t%-30s\t%-60s\n", "KIND", "ID", "NAME", "REASON")
+	for _, kind := range gcKinds {
+		for _, cand := range candidates {
+			if cand.Kind == kind {
+				fmt.Printf("%-12s\t%-40s\t%-30s\t%-60s\n", cand.Kind, cand.ID, cand.Name, cand.Reason)
+			}
+		}
+	}
+
+	if !apply {
+		fmt.Printf("\n%d entities can be collected, run with --apply to delete them.\n", len(candidates))
+		return nil
+	}
+
+	if err := confirmProtected(c); err != nil {
+		return err
+	}
+	if err := confirmDestructive(c, fmt.Sprintf("\ndelete these %d entities?", len(candidates)), confirmWord("gc"), true); err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, kind := range gcKinds {
+		for _, cand := range candidates {
+			if cand.Kind != kind {
+				continue
+			}
+			if err := deleteObject(cand.requestURL); err != nil {
+				failed = append(failed, fmt.Sprintf("%s %s (%v)", cand.Kind, cand.ID, err))
+				continue
+			}
+			fmt.Printf("delete %s %s\n", cand.Kind, cand.ID)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to delete: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+//gcOrphans find the entities of the state that reference nothing useful.
+func gcOrphans(state *gatewayState) []gcCandidate {
+	var candidates []gcCandidate
+
+	services, routes, consumers, upstreams := map[string]bool{}, map[string]bool{}, map[string]bool{}, map[string]bool{}
+	hosts := map[string]bool{}
+	for _, s := range state.Services {
+		services[s.ID] = true
+		hosts[strings.ToLower(s.Host)] = true
+	}
+	for _, r := range state.Routes {
+		routes[r.ID] = true
+	}
+	for _, cm := range state.Consumers {
+		consumers[cm.ID] = true
+	}
+	for _, u := range state.Upstreams {
+		upstreams[u.ID] = true
+	}
+
+	for _, r := range state.Routes {
+		if !services[r.Service.ID] {
+			candidates = append(candidates, gcCandidate{Kind: "route", ID: r.ID, Reason: fmt.Sprintf("service %s is deleted", r.Service.ID),
+				requestURL: fmt.Sprintf("%s/%s", ROUTE_RESOURCE_OBJECT, r.ID)})
+		}
+	}
+
+	for _, p := range state.Plugins {
+		var missing []string
+		if p.RouteID.ID != "" && !routes[p.RouteID.ID] {
+			missing = append(missing, "route "+p.RouteID.ID)
+		}
+		if p.ServiceID.ID != "" && !services[p.ServiceID.ID] {
+			missing = append(missing, "service "+p.ServiceID.ID)
+		}
+		if p.ConsumerID.ID != "" && !consumers[p.ConsumerID.ID] {
+			missing = append(missing, "consumer "+p.ConsumerID.ID)
+		}
+		if len(missing) > 0 {
+			candidates = append(candidates, gcCandidate{Kind: "plugin", ID: p.ID, Name: p.Name, Reason: strings.Join(missing, ", ") + " deleted",
+				requestURL: fmt.Sprintf("%s/%s", PLUGIN_RESOURCE_OBJECT, p.ID)})
+		}
+	}
+
+	for _, u := range state.Upstreams {
+		if !hosts[strings.ToLower(u.Name)] {
+			candidates = append(candidates, gcCandidate{Kind: "upstream", ID: u.ID, Name: u.Name, Reason: "no service host matches the upstream name",
+				requestURL: fmt.Sprintf("%s/%s", UPSTREAM_RESOURCE_OBJECT, u.ID)})
+		}
+	}
+
+	for _, t := range state.Targets {
+		if !upstreams[t.UpstreamID] {
+			candidates = append(candidates, gcCandidate{Kind: "target", ID: t.ID, Name: t.Target, Reason: fmt.Sprintf("upstream %s is deleted", t.UpstreamID),
+				requestURL: fmt.Sprintf("%s/%s/%s/%s", UPSTREAM_RESOURCE_OBJECT, t.UpstreamID, TARGET_RESOURCE_OBJECT, t.ID)})
+		}
+	}
+
+	withSNI := map[string]bool{}
+	for _, sni := range state.SNIs {
+		withSNI[sni.Certificate.ID] = true
+	}
+	for _, cert := range state.Certificates {
+		if len(cert.SNIs) == 0 && !withSNI[cert.ID] {
+			candidates = append(candidates, gcCandidate{Kind: "certificate", ID: cert.ID, Reason: "no sni uses the certificate",
+				requestURL: fmt.Sprintf("%s/%s", CERTIFICATE_RESOURCE_OBJECT, cert.ID)})
+		}
+	}
+
+	return candidates
+}
+
+//gcConsumersWithoutCredentials find the consumers without a credential of any authentication plugin.
+func gcConsumersWithoutCredentials(state *gatewayState) ([]gcCandidate, error) {
+	var candidates []gcCandidate
+
+	for _, cm := range state.Consumers {
+		credentials, queried := 0, 0
+		for _, kind := range consumerCredentials {
+			objects, err := listAllObjects(fmt.Sprintf("%s/%s/%s", CONSUMER_RESOURCE_OBJECT, cm.ID, kind.plugin), nil)
+			if isNotFound(err) {
+				//the auth plugin is not installed on this kong node.
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to list the %s credentials of consumer %s: %v", kind.plugin, cm.ID, err)
+			}
+			queried++
+			credentials += len(objects)
+		}
+
+		//without a credential endpoint, nothing says the consumer is unused.
+		if queried > 0 && credentials == 0 {
+			candidates = append(candidates, gcCandidate{Kind: "consumer", ID: cm.ID, Name: cm.Username, Reason: "no credential",
+				requestURL: fmt.Sprintf("%s/%s", CONSUMER_RESOURCE_OBJECT, cm.ID)})
+		}
+	}
+
+	return candidates, nil
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestGCOrphans(t *testing.T) {
+	state := &gatewayState{
+		Services: []ServiceConfig{{ID: "s1", Name: "orders", Host: "orders.upstream"}},
+		Routes: []RouteConfig{
+			{ID: "r1", Service: ServiceID{ID: "s1"}},
+			{ID: "r2", Service: ServiceID{ID: "deleted"}},
+		},
+		Consumers: []ConsumerConfig{{ID: "c1"}},
+		Plugins: []CommonPluginConfig{
+			{ID: "p1", Name: "key-auth", RouteID: Route{ID: "r1"}},
+			{ID: "p2", Name: "key-auth", RouteID: Route{ID: "r9"}},
+			{ID: "p3", Name: "rate-limiting", ConsumerID: Comsumner{ID: "c9"}},
+			{ID: "p4", Name: "cors"},
+		},
+		Upstreams: []UpstreamConfig{{ID: "u1", Name: "orders.upstream"}, {ID: "u2", Name: "unused.upstream"}},
+		Targets: []TargetConfig{
+			{ID: "t1", UpstreamID: "u1", Target: "10.0.0.1:80"},
+			{ID: "t2", UpstreamID: "u9", Target: "10.0.0.2:80"},
+		},
+		Certificates: []CertificateConfig{{ID: "cert1", SNIs: []string{"a.example.com"}}, {ID: "cert2"}},
+	}
+
+	var got []string
+	for _, cand := range gcOrphans(state) {
+		got = append(got, cand.Kind+" "+cand.ID)
+	}
+	sort.Strings(got)
+
+	want := []string{"certificate cert2", "plugin p2", "plugin p3", "route r2", "target t2", "upstream u2"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestGCConsumersWithoutCredentials(t *testing.T) {
+	state := &gatewayState{Consumers: []ConsumerConfig{{ID: "c1"}, {ID: "c2"}}}
+
+	cases := []struct {
+		name      string
+		responses map[string]fakeResponse
+		want      []string
+		wantErr   bool
+	}{
+		{
+			name: "consumer with a credential is kept",
+			responses: map[string]fakeResponse{
+				"/consumers/c1/key-auth": {http.StatusOK, `{"data":[{"id":"k1"}]}`},
+				"/consumers/c2/key-auth": {http.StatusOK, `{"data":[]}`},
+			},
+			want: []string{"c2"},
+		},
+		{
+			name:      "no credential endpoint is installed",
+			responses: map[string]fakeResponse{},
+			want:      nil,
+		},
+		{
+			name: "an admin api failure is an error, not a missing credential",
+			responses: map[string]fakeResponse{
+				"/consumers/c1/key-auth": {http.StatusOK, `{"data":[]}`},
+				"/consumers/c1/jwt":      {http.StatusInternalServerError, `{"message":"An unexpected error occurred"}`},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		restore := fakeKong(t, tc.responses)
+		candidates, err := gcConsumersWithoutCredentials(state)
+		restore()
+
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tc.name, err, tc.wantErr)
+			continue
+		}
+
+		var got []string
+		for _, cand := range candidates {
+			got = append(got, cand.ID)
+		}
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
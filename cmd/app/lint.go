@@ -0,0 +1,251 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/common/config"
+)
+
+// The lint commands run a set of rules over the gateway configuration, fetched from kong or read from a state file,
+// and exit with an error when a finding of severity error is reported, so they can gate a CI pipeline.
+// A rule is a check function registered in lintRules. The rules are enabled, given a severity and
+// options in the lint section of the kongctl config, and per context in the lint section of a context.
+
+const (
+	SEVERITY_ERROR   = "error"
+	SEVERITY_WARNING = "warning"
+	SEVERITY_INFO    = "info"
+)
+
+var severityLevels = map[string]int{
+	SEVERITY_ERROR:   0,
+	SEVERITY_WARNING: 1,
+	SEVERITY_INFO:    2,
+}
+
+//lintFinding is a problem reported by a lint rule on an entity.
+type lintFinding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Entity   string `json:"entity"`
+	ID       string `json:"id"`
+	Message  string `json:"message"`
+}
+
+//lintRule is a check of the gateway configuration.
+type lintRule struct {
+	Name        string
+	Description string
+	//The default severity of the findings.
+	Severity string
+	//The default options, overridden by the config.
+	Options map[string]float64
+	//Enabled is set from the config, every rule is enabled by default.
+	Enabled bool
+	//Check return the findings, their rule and severity are set by the runner.
+	Check func(l *lintContext) []lintFinding
+}
+
+//lintContext is what a rule checks: the state, and the options of the rule.
+type lintContext struct {
+	State   *gatewayState
+	Options map[string]float64
+	Now     time.Time
+}
+
+//lintRules are every available rule, add a rule here to plug it in.
+var lintRules = []lintRule{
+	{Name: RULE_ROUTE_DUPLICATE, Description: "routes with the same protocols, hosts, paths and methods", Severity: SEVERITY_ERROR, Check: lintRouteDuplicates},
+	{Name: RULE_ROUTE_UNREACHABLE, Description: "routes whose requests are all matched first by another route", Severity: SEVERITY_ERROR, Check: lintRouteUnreachable},
+	{Name: RULE_ROUTE_AMBIGUOUS_REGEX, Description: "regex paths of the same regex_priority that may match the same requests", Severity: SEVERITY_WARNING, Check: lintRouteAmbiguousRegex},
+	{Name: RULE_ROUTE_PROTOCOL_MISMATCH, Description: "routes whose protocols can not proxy to their service", Severity: SEVERITY_ERROR, Check: lintRouteProtocols},
+	{Name: RULE_SERVICE_WITHOUT_ROUTES, Description: "services without any route", Severity: SEVERITY_WARNING, Check: lintServiceWithoutRoutes},
+	{Name: RULE_ROUTE_WITHOUT_AUTH, Description: "routes without an authentication plugin on the route, its service or globally", Severity: SEVERITY_WARNING, Check: lintRouteWithoutAuth},
+	{Name: RULE_UPSTREAM_FEW_TARGETS, Description: "upstreams with fewer than min_targets active targets", Severity: SEVERITY_WARNING, Options: map[string]float64{"min_targets": 2}, Check: lintUpstreamFewTargets},
+	{Name: RULE_PLUGIN_DISABLED, Description: "plugins disabled and created more than days ago", Severity: SEVERITY_INFO, Options: map[string]float64{"days": 30}, Check: lintPluginDisabled},
+	{Name: RULE_SERVICE_TIMEOUT, Description: "services with a connect, read or write timeout above max_timeout milliseconds", Severity: SEVERITY_WARNING, Options: map[string]float64{"max_timeout": 60000}, Check: lintServiceTimeout},
+	{Name: RULE_SERVICE_NO_RETRIES, Description: "services with retries set to 0", Severity: SEVERITY_WARNING, Check: lintServiceNoRetries},
+}
+
+var lintFlags = []cli.Flag{
+	cli.StringFlag{Name: "state", Usage: "a state file holding the configuration, instead of fetching it from kong"},
+	cli.StringFlag{Name: "output", Value: "table", Usage: "the output format: table, json, sarif, junit"},
+	cli.StringSliceFlag{Name: "rule", Usage: "run only this rule, repeat the flag to run several rules"},
+}
+
+var LintCommand = cli.Command{
+	Name:   "lint",
+	Usage:  "Check the kong configuration for mistakes.",
+	Flags:  lintFlags,
+	Action: lintAll,
+
+	Subcommands: []cli.Command{
+		{
+			Name:   "routes",
+			Usage:  "detect duplicated, unreachable and ambiguous routes, and routes whose protocols mismatch their service",
+			Flags:  lintFlags,
+			Action: lintRoutesCommand,
+		},
+		{
+			Name:   "rules",
+			Usage:  "list the lint rules with their settings in the current context",
+			Action: listLintRules,
+		},
+	},
+}
+
+func lintAll(c *cli.Context) error {
+	return runLint(c, "", true)
+}
+
+func lintRoutesCommand(c *cli.Context) error {
+	return runLint(c, "route-", false)
+}
+
+//runLint run the enabled rules whose name starts with prefix, and report the findings.
+func runLint(c *cli.Context, prefix string, full bool) error {
+	rules, err := configuredLintRules(c)
+	if err != nil {
+		return err
+	}
+
+	only := c.StringSlice("rule")
+	for _, name := range only {
+		if findLintRule(name) == nil {
+			return fmt.Errorf("lint rule %s is not found, see kongctl lint rules", name)
+		}
+		if !strings.HasPrefix(name, prefix) {
+			return fmt.Errorf("lint rule %s is not a %s rule, run it with kongctl lint", name, strings.TrimSuffix(prefix, "-"))
+		}
+	}
+
+	state, err := loadGatewayState(c.String("state"), full)
+	if err != nil {
+		return err
+	}
+
+	var findings []lintFinding
+	var ran []lintRule
+	for _, rule := range rules {
+		if !strings.HasPrefix(rule.Name, prefix) {
+			continue
+		}
+		if len(only) > 0 && !containsFold(only, rule.Name) {
+			continue
+		}
+		if len(only) == 0 && !rule.Enabled {
+			continue
+		}
+
+		ran = append(ran, rule)
+		for _, f := range rule.Check(&lintContext{State: state, Options: rule.Options, Now: time.Now()}) {
+			f.Rule, f.Severity = rule.Name, rule.Severity
+			findings = append(findings, f)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Severity != findings[j].Severity {
+			return severityLevels[findings[i].Severity] < severityLevels[findings[j].Severity]
+		}
+		return findings[i].Rule < findings[j].Rule
+	})
+
+	if err := writeFindings(c.String("output"), ran, findings); err != nil {
+		return err
+	}
+
+	errors := 0
+	for _, f := range findings {
+		if f.Severity == SEVERITY_ERROR {
+			errors++
+		}
+	}
+	if errors > 0 {
+		return fmt.Errorf("lint found %d error(s)", errors)
+	}
+	return nil
+}
+
+//configuredLintRules return the rules with the enabled state, severity and options of the config and the current context.
+func configuredLintRules(c *cli.Context) ([]lintRule, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	_, kctx, err := cfg.ActiveContext(c.GlobalString("context"))
+	if err != nil {
+		return nil, err
+	}
+
+	settings := cfg.LintRules(kctx)
+	for name := range settings {
+		if findLintRule(name) == nil {
+			return nil, fmt.Errorf("lint rule %s of %s is not found, see kongctl lint rules", name, config.Path())
+		}
+	}
+
+	rules := make([]lintRule, 0, len(lintRules))
+	for _, rule := range lintRules {
+		options := map[string]float64{}
+		for k, v := range rule.Options {
+			options[k] = v
+		}
+
+		setting := settings[rule.Name]
+		for k, v := range setting.Options {
+			if _, ok := options[k]; !ok {
+				return nil, fmt.Errorf("lint rule %s has no option %s", rule.Name, k)
+			}
+			options[k] = v
+		}
+		rule.Enabled = setting.Enabled == nil || *setting.Enabled
+		if setting.Severity != "" {
+			if _, ok := severityLevels[setting.Severity]; !ok {
+				return nil, fmt.Errorf("severity %s of lint rule %s is invalid, available severities are error, warning, info", setting.Severity, rule.Name)
+			}
+			rule.Severity = setting.Severity
+		}
+
+		rule.Options = options
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func findLintRule(name string) *lintRule {
+	for i := range lintRules {
+		if lintRules[i].Name == name {
+			return &lintRules[i]
+		}
+	}
+	return nil
+}
+
+//listLintRules print every rule with its settings.
+func listLintRules(c *cli.Context) error {
+	rules, err := configuredLintRules(c)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-24s\t%-8s\t%-8s\t%-20s\t%-60s\n", "RULE", "ENABLED", "SEVERITY", "OPTIONS", "DESCRIPTION")
+	for _, rule := range rules {
+		var options []string
+		for k, v := range rule.Options {
+			options = append(options, fmt.Sprintf("%s=%g", k, v))
+		}
+		sort.Strings(options)
+
+		fmt.Printf("%-24s\t%-8t\t%-8s\t%-20s\t%-60s\n", rule.Name, rule.Enabled, rule.Severity, strings.Join(options, ","), rule.Description)
+	}
+
+	return nil
+}
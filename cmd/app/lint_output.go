@@ -0,0 +1,189 @@
+package app
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+)
+
+// The lint findings are printed as a table, as json, as SARIF 2.1.0 for code scanning tools,
+// or as a JUnit report with a test case per rule and entity for CI test reports.
+
+const (
+	SARIF_VERSION = "2.1.0"
+	SARIF_SCHEMA  = "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json"
+)
+
+//the SARIF level of the severities.
+var sarifLevels = map[string]string{
+	SEVERITY_ERROR:   "error",
+	SEVERITY_WARNING: "warning",
+	SEVERITY_INFO:    "note",
+}
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Type    string `xml:"type,attr"`
+	Message string `xml:"message,attr"`
+}
+
+//writeFindings print the findings of the rules that ran in an output format.
+func writeFindings(output string, rules []lintRule, findings []lintFinding) error {
+	switch output {
+	case "table":
+		fmt.Printf("%-8s\t%-24s\t%-8s\t%-40s\t%-60s\n", "SEVERITY", "RULE", "ENTITY", "ID", "MESSAGE")
+		for _, f := range findings {
+			fmt.Printf("%-8s\t%-24s\t%-8s\t%-40s\t%-60s\n", f.Severity, f.Rule, f.Entity, f.ID, f.Message)
+		}
+		return nil
+	case "json":
+		if findings == nil {
+			findings = []lintFinding{}
+		}
+		return writeJSON(findings)
+	case "sarif":
+		return writeJSON(sarifReport(rules, findings))
+	case "junit":
+		data, err := xml.MarshalIndent(junitReport(rules, findings), "", "\t")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s%s\n", xml.Header, data)
+		return nil
+	default:
+		return fmt.Errorf("output %s is invalid, available outputs are table, json, sarif, junit", output)
+	}
+}
+
+func writeJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(v)
+}
+
+func sarifReport(rules []lintRule, findings []lintFinding) sarifLog {
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: "kongctl", Rules: []sarifRule{}}},
+		Results: []sarifResult{},
+	}
+
+	for _, rule := range rules {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{
+			ID:                   rule.Name,
+			ShortDescription:     sarifMessage{Text: rule.Description},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevels[rule.Severity]},
+		})
+	}
+
+	for _, f := range findings {
+		run.Results = append(run.Results, sarifResult{
+			RuleID:  f.Rule,
+			Level:   sarifLevels[f.Severity],
+			Message: sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{{
+				LogicalLocations: []sarifLogicalLocation{{Name: f.ID, FullyQualifiedName: fmt.Sprintf("%s/%s", f.Entity, f.ID), Kind: f.Entity}},
+			}},
+		})
+	}
+
+	return sarifLog{Version: SARIF_VERSION, Schema: SARIF_SCHEMA, Runs: []sarifRun{run}}
+}
+
+//junitReport return a test suite per rule, a rule without findings is a passing test case.
+func junitReport(rules []lintRule, findings []lintFinding) junitTestSuites {
+	report := junitTestSuites{}
+
+	for _, rule := range rules {
+		suite := junitTestSuite{Name: rule.Name}
+
+		for _, f := range findings {
+			if f.Rule != rule.Name {
+				continue
+			}
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      fmt.Sprintf("%s/%s", f.Entity, f.ID),
+				ClassName: rule.Name,
+				Failure:   &junitFailure{Type: f.Severity, Message: f.Message},
+			})
+			suite.Failures++
+		}
+
+		if len(suite.Cases) == 0 {
+			suite.Cases = append(suite.Cases, junitTestCase{Name: rule.Description, ClassName: rule.Name})
+		}
+
+		suite.Tests = len(suite.Cases)
+		report.Suites = append(report.Suites, suite)
+	}
+
+	return report
+}
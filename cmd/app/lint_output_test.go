@@ -0,0 +1,63 @@
+package app
+
+import (
+	"testing"
+)
+
+func TestLintReports(t *testing.T) {
+	rules := []lintRule{
+		{Name: RULE_ROUTE_DUPLICATE, Description: "duplicates", Severity: SEVERITY_ERROR},
+		{Name: RULE_SERVICE_NO_RETRIES, Description: "no retries", Severity: SEVERITY_INFO},
+	}
+	findings := []lintFinding{
+		{Rule: RULE_ROUTE_DUPLICATE, Severity: SEVERITY_ERROR, Entity: "route", ID: "r2", Message: "same as r1"},
+		{Rule: RULE_ROUTE_DUPLICATE, Severity: SEVERITY_WARNING, Entity: "route", ID: "r3", Message: "same as r1"},
+	}
+
+	sarif := sarifReport(rules, findings)
+	if sarif.Version != SARIF_VERSION || len(sarif.Runs) != 1 {
+		t.Fatalf("sarif: got version %s and %d runs", sarif.Version, len(sarif.Runs))
+	}
+
+	run := sarif.Runs[0]
+	ruleLevels := []struct{ id, level string }{{RULE_ROUTE_DUPLICATE, "error"}, {RULE_SERVICE_NO_RETRIES, "note"}}
+	for i, want := range ruleLevels {
+		if got := run.Tool.Driver.Rules[i]; got.ID != want.id || got.DefaultConfiguration.Level != want.level {
+			t.Errorf("sarif rule %d: got %s %s, want %s %s", i, got.ID, got.DefaultConfiguration.Level, want.id, want.level)
+		}
+	}
+
+	resultLevels := []string{"error", "warning"}
+	for i, want := range resultLevels {
+		got := run.Results[i]
+		if got.Level != want || got.Locations[0].LogicalLocations[0].FullyQualifiedName != "route/"+findings[i].ID {
+			t.Errorf("sarif result %d: got %+v", i, got)
+		}
+	}
+
+	if empty := sarifReport(nil, nil); empty.Runs[0].Results == nil || empty.Runs[0].Tool.Driver.Rules == nil {
+		t.Errorf("sarif: an empty report must have empty rules and results, not null")
+	}
+
+	junit := junitReport(rules, findings)
+	suites := []struct {
+		name            string
+		tests, failures int
+	}{
+		{RULE_ROUTE_DUPLICATE, 2, 2},
+		{RULE_SERVICE_NO_RETRIES, 1, 0},
+	}
+
+	if len(junit.Suites) != len(suites) {
+		t.Fatalf("junit: got %d suites, want %d", len(junit.Suites), len(suites))
+	}
+	for i, want := range suites {
+		got := junit.Suites[i]
+		if got.Name != want.name || got.Tests != want.tests || got.Failures != want.failures {
+			t.Errorf("junit suite %d: got %s %d/%d, want %s %d/%d", i, got.Name, got.Failures, got.Tests, want.name, want.failures, want.tests)
+		}
+	}
+	if c := junit.Suites[1].Cases[0]; c.Failure != nil || c.Name != "no retries" {
+		t.Errorf("junit: a rule without findings must be a passing case, got %+v", c)
+	}
+}
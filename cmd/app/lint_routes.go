@@ -0,0 +1,260 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// The route rules follow the router ordering of the route match: a route is unreachable when,
+// for every request it matches, another route is evaluated first and matches too.
+
+const (
+	RULE_ROUTE_DUPLICATE         = "route-duplicate"
+	RULE_ROUTE_UNREACHABLE       = "route-unreachable"
+	RULE_ROUTE_AMBIGUOUS_REGEX   = "route-ambiguous-regex"
+	RULE_ROUTE_PROTOCOL_MISMATCH = "route-protocol-mismatch"
+)
+
+//the protocol families, a route only proxies to a service of its family.
+var protocolFamilies = map[string]string{
+	"http":  "http",
+	"https": "http",
+	"grpc":  "http",
+	"grpcs": "http",
+	"tcp":   "stream",
+	"tls":   "stream",
+	"udp":   "stream",
+}
+
+//lintRouteDuplicates report the routes with the same conditions as a route evaluated before them.
+func lintRouteDuplicates(l *lintContext) []lintFinding {
+	var findings []lintFinding
+	routes := l.State.Routes
+
+	for i := range routes {
+		for j := 0; j < i; j++ {
+			if routeSignature(&routes[j]) != routeSignature(&routes[i]) {
+				continue
+			}
+			findings = append(findings, lintFinding{
+				Entity:  "route",
+				ID:      routes[i].ID,
+				Message: fmt.Sprintf("same hosts, paths and methods as route %s (%s), it never matches", routes[j].ID, routeSignature(&routes[j])),
+			})
+			break
+		}
+	}
+
+	return findings
+}
+
+//lintRouteUnreachable report the routes shadowed by another route, duplicates are left to route-duplicate.
+func lintRouteUnreachable(l *lintContext) []lintFinding {
+	var findings []lintFinding
+	routes := l.State.Routes
+
+	for i := range routes {
+		b := &routes[i]
+		for j := range routes {
+			a := &routes[j]
+			if i == j || routeSignature(a) == routeSignature(b) || !shadows(a, j, b, i) {
+				continue
+			}
+			findings = append(findings, lintFinding{
+				Entity:  "route",
+				ID:      b.ID,
+				Message: fmt.Sprintf("every request it matches is matched first by route %s", a.ID),
+			})
+			break
+		}
+	}
+
+	return findings
+}
+
+//routeSignature return the sorted protocols, hosts, paths and methods of a route.
+func routeSignature(r *RouteConfig) string {
+	sorted := func(list []string, fold bool) string {
+		l := make([]string, 0, len(list))
+		for _, v := range list {
+			if fold {
+				v = strings.ToLower(v)
+			}
+			l = append(l, v)
+		}
+		sort.Strings(l)
+		return strings.Join(l, ",")
+	}
+
+	return fmt.Sprintf("protocols=%s hosts=%s paths=%s methods=%s",
+		sorted(r.Protocols, true), sorted(r.Hosts, true), sorted(r.Paths, false), sorted(r.Methods, true))
+}
+
+//shadows report whether route a, at index ai, is evaluated before route b, at index bi, for every request b matches.
+func shadows(a *RouteConfig, ai int, b *RouteConfig, bi int) bool {
+	//a route of another category never shadows b: more conditions are evaluated first but match less,
+	//and a route with as many other conditions does not check the conditions of b, so b matches requests it does not.
+	if !sameCategory(a, b) {
+		return false
+	}
+
+	if len(a.Protocols) > 0 {
+		for _, p := range b.Protocols {
+			if !containsFold(a.Protocols, p) {
+				return false
+			}
+		}
+		if len(b.Protocols) == 0 {
+			return false
+		}
+	}
+
+	for _, m := range b.Methods {
+		if !containsFold(a.Methods, m) {
+			return false
+		}
+	}
+
+	for _, h := range b.Hosts {
+		if !containsFold(a.Hosts, h) {
+			return false
+		}
+	}
+
+	for _, p := range b.Paths {
+		covered := false
+		for _, q := range a.Paths {
+			if pathShadows(q, a.RegexPriority, ai, p, b.RegexPriority, bi) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+
+	return true
+}
+
+//pathShadows report whether the path q of a route is evaluated before the path p of another route,
+//and matches every request p matches.
+func pathShadows(q string, qPriority, qIndex int, p string, pPriority, pIndex int) bool {
+	qRegex, pRegex := isRegexPath(q), isRegexPath(p)
+
+	switch {
+	case !qRegex && !pRegex:
+		//longer prefixes first, the same prefix by route order.
+		return q == p && qIndex < pIndex
+	case qRegex && !pRegex:
+		//regexes are evaluated before prefixes. A regex open at the end matching the prefix
+		//matches every path starting with the prefix too.
+		if strings.HasSuffix(q, "$") {
+			return false
+		}
+		re, err := regexp.Compile("^" + q)
+		return err == nil && re.MatchString(p)
+	case qRegex && pRegex:
+		return q == p && (qPriority > pPriority || qPriority == pPriority && qIndex < pIndex)
+	default:
+		return false
+	}
+}
+
+//lintRouteAmbiguousRegex report the regex paths of the same regex_priority that may match the same requests,
+//the route evaluated first then depends on the order of the routes.
+func lintRouteAmbiguousRegex(l *lintContext) []lintFinding {
+	var findings []lintFinding
+	routes := l.State.Routes
+
+	for i := range routes {
+		for j := i + 1; j < len(routes); j++ {
+			a, b := &routes[i], &routes[j]
+			if a.RegexPriority != b.RegexPriority || !sameCategory(a, b) || !overlaps(a.Hosts, b.Hosts) || !overlaps(a.Methods, b.Methods) {
+				continue
+			}
+
+			for _, p := range a.Paths {
+				for _, q := range b.Paths {
+					if p == q || !isRegexPath(p) || !isRegexPath(q) || !literalPrefixesOverlap(p, q) {
+						continue
+					}
+					findings = append(findings, lintFinding{
+						Entity:  "route",
+						ID:      b.ID,
+						Message: fmt.Sprintf("regex path %s and %s of route %s have the same regex_priority %d", q, p, a.ID, a.RegexPriority),
+					})
+				}
+			}
+		}
+	}
+
+	return findings
+}
+
+func sameCategory(a, b *RouteConfig) bool {
+	return routeCategory(a) == routeCategory(b)
+}
+
+//overlaps report whether two conditions share a value, an empty condition matches everything.
+func overlaps(a, b []string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return true
+	}
+	for _, v := range a {
+		if containsFold(b, v) {
+			return true
+		}
+	}
+	return false
+}
+
+var regexMeta = regexp.MustCompile(`[\\^$.|?*+()\[\]{}]`)
+
+//literalPrefixesOverlap report whether the literal prefix of a regex is a prefix of the literal prefix of the other.
+func literalPrefixesOverlap(p, q string) bool {
+	literal := func(s string) string {
+		if loc := regexMeta.FindStringIndex(s); loc != nil {
+			return s[:loc[0]]
+		}
+		return s
+	}
+
+	lp, lq := literal(p), literal(q)
+	return strings.HasPrefix(lp, lq) || strings.HasPrefix(lq, lp)
+}
+
+//lintRouteProtocols report the routes whose protocols can not proxy to their service, or without a service.
+func lintRouteProtocols(l *lintContext) []lintFinding {
+	var findings []lintFinding
+	state := l.State
+
+	for i := range state.Routes {
+		r := &state.Routes[i]
+
+		service := state.service(r.Service.ID)
+		if service == nil {
+			findings = append(findings, lintFinding{
+				Entity:  "route",
+				ID:      r.ID,
+				Message: fmt.Sprintf("service %s is not found", r.Service.ID),
+			})
+			continue
+		}
+
+		family := protocolFamilies[strings.ToLower(service.Protocol)]
+		for _, p := range r.Protocols {
+			if protocolFamilies[strings.ToLower(p)] != family {
+				findings = append(findings, lintFinding{
+					Entity:  "route",
+					ID:      r.ID,
+					Message: fmt.Sprintf("protocol %s can not proxy to service %s of protocol %s", p, service.Name, service.Protocol),
+				})
+			}
+		}
+	}
+
+	return findings
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintRouteChecks(t *testing.T) {
+	cases := []struct {
+		name  string
+		check func(l *lintContext) []lintFinding
+		state *gatewayState
+		want  []string
+	}{
+		{
+			name:  "duplicates in another order are reported once",
+			check: lintRouteDuplicates,
+			state: &gatewayState{Routes: []RouteConfig{
+				{ID: "r1", Hosts: []string{"a.example.com", "B.example.com"}, Paths: []string{"/orders"}},
+				{ID: "r2", Hosts: []string{"b.example.com", "a.example.com"}, Paths: []string{"/orders"}},
+				{ID: "r3", Hosts: []string{"a.example.com"}, Paths: []string{"/orders"}},
+			}},
+			want: []string{"r2"},
+		},
+		{
+			name:  "a route after the same prefix with fewer methods is unreachable",
+			check: lintRouteUnreachable,
+			state: &gatewayState{Routes: []RouteConfig{
+				{ID: "r1", Paths: []string{"/orders"}, Methods: []string{"GET", "POST"}},
+				{ID: "r2", Paths: []string{"/orders"}, Methods: []string{"GET"}},
+			}},
+			want: []string{"r2"},
+		},
+		{
+			name:  "an open regex shadows the prefixes it matches",
+			check: lintRouteUnreachable,
+			state: &gatewayState{Routes: []RouteConfig{
+				{ID: "r1", Paths: []string{"/orders/items"}},
+				{ID: "r2", Paths: []string{`/orders/\w+`}},
+			}},
+			want: []string{"r1"},
+		},
+		{
+			name:  "a route of another category is not shadowed",
+			check: lintRouteUnreachable,
+			state: &gatewayState{Routes: []RouteConfig{
+				{ID: "r1", Hosts: []string{"example.com"}, Paths: []string{"/orders"}},
+				{ID: "r2", Paths: []string{"/orders"}},
+			}},
+			want: nil,
+		},
+		{
+			name:  "regexes of the same priority with overlapping prefixes are ambiguous",
+			check: lintRouteAmbiguousRegex,
+			state: &gatewayState{Routes: []RouteConfig{
+				{ID: "r1", Paths: []string{`/orders/\d+`}},
+				{ID: "r2", Paths: []string{`/orders/[a-z0-9]+`}},
+				{ID: "r3", Paths: []string{`/orders/[a-z]+`}, RegexPriority: 5},
+				{ID: "r4", Paths: []string{`/users/\d+`}},
+			}},
+			want: []string{"r2"},
+		},
+		{
+			name:  "protocols of another family or a missing service",
+			check: lintRouteProtocols,
+			state: &gatewayState{
+				Services: []ServiceConfig{{ID: "s1", Protocol: "http"}},
+				Routes: []RouteConfig{
+					{ID: "r1", Service: ServiceID{ID: "s1"}, Protocols: []string{"http", "https"}},
+					{ID: "r2", Service: ServiceID{ID: "s1"}, Protocols: []string{"tcp"}},
+					{ID: "r3", Service: ServiceID{ID: "s9"}},
+				},
+			},
+			want: []string{"r2", "r3"},
+		},
+	}
+
+	for _, tc := range cases {
+		var got []string
+		for _, f := range tc.check(&lintContext{State: tc.state}) {
+			got = append(got, f.ID)
+		}
+
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
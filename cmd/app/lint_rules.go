@@ -0,0 +1,144 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/xigang/kongctl/pkg/plugin/authentication"
+)
+
+const (
+	RULE_SERVICE_WITHOUT_ROUTES = "service-without-routes"
+	RULE_ROUTE_WITHOUT_AUTH     = "route-without-auth"
+	RULE_UPSTREAM_FEW_TARGETS   = "upstream-few-targets"
+	RULE_PLUGIN_DISABLED        = "plugin-disabled"
+	RULE_SERVICE_TIMEOUT        = "service-timeout"
+	RULE_SERVICE_NO_RETRIES     = "service-no-retries"
+)
+
+//the plugins authenticating the consumers.
+var authPlugins = []string{
+	authentication.PLUGIN_BASIC_AUTH,
+	authentication.PLUGIN_KEY_AUTH,
+	"jwt",
+	"oauth2",
+	"hmac-auth",
+	"ldap-auth",
+}
+
+func lintServiceWithoutRoutes(l *lintContext) []lintFinding {
+	routed := map[string]bool{}
+	for _, r := range l.State.Routes {
+		routed[r.Service.ID] = true
+	}
+
+	var findings []lintFinding
+	for _, s := range l.State.Services {
+		if !routed[s.ID] {
+			findings = append(findings, lintFinding{Entity: "service", ID: s.ID, Message: fmt.Sprintf("service %s has no route, it receives no traffic", s.Name)})
+		}
+	}
+	return findings
+}
+
+//lintRouteWithoutAuth report the routes no enabled authentication plugin applies to.
+//It is skipped when the plugins are not loaded, every route would be reported.
+func lintRouteWithoutAuth(l *lintContext) []lintFinding {
+	if !l.State.PluginsLoaded {
+		fmt.Fprintf(os.Stderr, "warning: %s is skipped, the plugins are not loaded, run kongctl lint or use a state file with plugins\n", RULE_ROUTE_WITHOUT_AUTH)
+		return nil
+	}
+
+	var findings []lintFinding
+
+	for _, r := range l.State.Routes {
+		authenticated := false
+		for _, p := range l.State.Plugins {
+			if !p.Enabled || !containsFold(authPlugins, p.Name) || p.ConsumerID.ID != "" {
+				continue
+			}
+			if pluginPrecedence(p, r.ID, r.Service.ID, "") != 0 {
+				authenticated = true
+				break
+			}
+		}
+
+		if !authenticated {
+			findings = append(findings, lintFinding{Entity: "route", ID: r.ID, Message: "no authentication plugin on the route, its service or globally"})
+		}
+	}
+
+	return findings
+}
+
+func lintUpstreamFewTargets(l *lintContext) []lintFinding {
+	minTargets := int(l.Options["min_targets"])
+
+	var findings []lintFinding
+	for _, u := range l.State.Upstreams {
+		active := 0
+		for _, t := range l.State.Targets {
+			if t.UpstreamID == u.ID && t.Weight > 0 {
+				active++
+			}
+		}
+
+		if active < minTargets {
+			findings = append(findings, lintFinding{Entity: "upstream", ID: u.ID, Message: fmt.Sprintf("upstream %s has %d active target(s), fewer than %d", u.Name, active, minTargets)})
+		}
+	}
+	return findings
+}
+
+//lintPluginDisabled report the disabled plugins created more than days ago. Kong keeps no update time,
+//so a plugin disabled recently but created long ago is reported too.
+func lintPluginDisabled(l *lintContext) []lintFinding {
+	maxAge := time.Duration(l.Options["days"] * float64(24*time.Hour))
+
+	var findings []lintFinding
+	for _, p := range l.State.Plugins {
+		if p.Enabled || p.CreatedAt == 0 {
+			continue
+		}
+
+		created := kongTime(float64(p.CreatedAt))
+		if age := l.Now.Sub(created); age > maxAge {
+			findings = append(findings, lintFinding{Entity: "plugin", ID: p.ID, Message: fmt.Sprintf("plugin %s on %s is disabled and was created %d days ago", p.Name, pluginScope(p), int(age.Hours()/24))})
+		}
+	}
+	return findings
+}
+
+func lintServiceTimeout(l *lintContext) []lintFinding {
+	maxTimeout := int(l.Options["max_timeout"])
+
+	var findings []lintFinding
+	for _, s := range l.State.Services {
+		timeouts := []struct {
+			name  string
+			value int
+		}{
+			{"connect_timeout", s.ConnectTimeout},
+			{"read_timeout", s.ReadTimeout},
+			{"write_timeout", s.WriteTimeout},
+		}
+
+		for _, t := range timeouts {
+			if t.value > maxTimeout {
+				findings = append(findings, lintFinding{Entity: "service", ID: s.ID, Message: fmt.Sprintf("%s of service %s is %dms, above %dms", t.name, s.Name, t.value, maxTimeout)})
+			}
+		}
+	}
+	return findings
+}
+
+func lintServiceNoRetries(l *lintContext) []lintFinding {
+	var findings []lintFinding
+	for _, s := range l.State.Services {
+		if s.Retries == 0 {
+			findings = append(findings, lintFinding{Entity: "service", ID: s.ID, Message: fmt.Sprintf("service %s never retries a failed upstream connection", s.Name)})
+		}
+	}
+	return findings
+}
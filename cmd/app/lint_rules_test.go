@@ -0,0 +1,121 @@
+package app
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLintRuleChecks(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+
+	cases := []struct {
+		name    string
+		check   func(l *lintContext) []lintFinding
+		state   *gatewayState
+		options map[string]float64
+		want    []string
+	}{
+		{
+			name:  "service-without-routes",
+			check: lintServiceWithoutRoutes,
+			state: &gatewayState{
+				Services: []ServiceConfig{{ID: "s1"}, {ID: "s2"}},
+				Routes:   []RouteConfig{{ID: "r1", Service: ServiceID{ID: "s1"}}},
+			},
+			want: []string{"s2"},
+		},
+		{
+			name:  "route-without-auth",
+			check: lintRouteWithoutAuth,
+			state: &gatewayState{
+				Routes: []RouteConfig{
+					{ID: "r1", Service: ServiceID{ID: "s1"}},
+					{ID: "r2", Service: ServiceID{ID: "s2"}},
+					{ID: "r3", Service: ServiceID{ID: "s3"}},
+					{ID: "r4", Service: ServiceID{ID: "s4"}},
+				},
+				Plugins: []CommonPluginConfig{
+					{ID: "p1", Name: "key-auth", Enabled: true, RouteID: Route{ID: "r1"}},
+					{ID: "p2", Name: "jwt", Enabled: true, ServiceID: ServiceID{ID: "s2"}},
+					{ID: "p3", Name: "key-auth", Enabled: false, ServiceID: ServiceID{ID: "s3"}},
+					{ID: "p4", Name: "key-auth", Enabled: true, ServiceID: ServiceID{ID: "s4"}, ConsumerID: Comsumner{ID: "c1"}},
+					{ID: "p5", Name: "cors", Enabled: true},
+				},
+				PluginsLoaded: true,
+			},
+			want: []string{"r3", "r4"},
+		},
+		{
+			name:  "route-without-auth is skipped without plugins",
+			check: lintRouteWithoutAuth,
+			state: &gatewayState{Routes: []RouteConfig{{ID: "r1"}}},
+			want:  nil,
+		},
+		{
+			name:  "route-without-auth with a global plugin",
+			check: lintRouteWithoutAuth,
+			state: &gatewayState{
+				Routes:        []RouteConfig{{ID: "r1"}},
+				Plugins:       []CommonPluginConfig{{ID: "p1", Name: "basic-auth", Enabled: true}},
+				PluginsLoaded: true,
+			},
+			want: nil,
+		},
+		{
+			name:  "upstream-few-targets",
+			check: lintUpstreamFewTargets,
+			state: &gatewayState{
+				Upstreams: []UpstreamConfig{{ID: "u1"}, {ID: "u2"}},
+				Targets: []TargetConfig{
+					{UpstreamID: "u1", Weight: 100}, {UpstreamID: "u1", Weight: 100},
+					{UpstreamID: "u2", Weight: 100}, {UpstreamID: "u2", Weight: 0},
+				},
+			},
+			options: map[string]float64{"min_targets": 2},
+			want:    []string{"u2"},
+		},
+		{
+			name:  "plugin-disabled",
+			check: lintPluginDisabled,
+			state: &gatewayState{Plugins: []CommonPluginConfig{
+				{ID: "old", CreatedAt: now.Add(-40 * 24 * time.Hour).Unix()},
+				{ID: "recent", CreatedAt: now.Add(-2 * 24 * time.Hour).Unix()},
+				{ID: "old-ms", CreatedAt: now.Add(-40*24*time.Hour).Unix() * 1000},
+				{ID: "enabled", Enabled: true, CreatedAt: now.Add(-40 * 24 * time.Hour).Unix()},
+			}},
+			options: map[string]float64{"days": 30},
+			want:    []string{"old", "old-ms"},
+		},
+		{
+			name:  "service-timeout",
+			check: lintServiceTimeout,
+			state: &gatewayState{Services: []ServiceConfig{
+				{ID: "s1", ConnectTimeout: 60000, ReadTimeout: 60000, WriteTimeout: 60000},
+				{ID: "s2", ConnectTimeout: 60000, ReadTimeout: 120000, WriteTimeout: 120000},
+			}},
+			options: map[string]float64{"max_timeout": 60000},
+			want:    []string{"s2", "s2"},
+		},
+		{
+			name:    "service-no-retries",
+			check:   lintServiceNoRetries,
+			state:   &gatewayState{Services: []ServiceConfig{{ID: "s1", Retries: 5}, {ID: "s2"}}},
+			options: nil,
+			want:    []string{"s2"},
+		},
+	}
+
+	for _, tc := range cases {
+		var got []string
+		for _, f := range tc.check(&lintContext{State: tc.state, Options: tc.options, Now: now}) {
+			got = append(got, f.ID)
+		}
+		sort.Strings(got)
+
+		if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunLint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kongctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("KONGCTL_HOME", os.Getenv("KONGCTL_HOME"))
+	os.Setenv("KONGCTL_HOME", dir)
+
+	state := filepath.Join(dir, "state.json")
+	data := `{"services":[{"id":"s1","protocol":"http","retries":5}],"routes":[
+		{"id":"r1","service":{"id":"s1"},"paths":["/orders"]},
+		{"id":"r2","service":{"id":"s1"},"paths":["/orders"]}
+	]}`
+	if err := ioutil.WriteFile(state, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		prefix string
+		rules  []string
+		err    string
+	}{
+		{name: "a rule of another subcommand", prefix: "route-", rules: []string{RULE_SERVICE_TIMEOUT}, err: "is not a route rule"},
+		{name: "an unknown rule", prefix: "route-", rules: []string{"route-unknown"}, err: "is not found"},
+		{name: "a passing rule", prefix: "route-", rules: []string{RULE_ROUTE_PROTOCOL_MISMATCH}},
+		{name: "a failing rule", prefix: "route-", rules: []string{RULE_ROUTE_DUPLICATE}, err: "lint found 1 error(s)"},
+		{name: "every route rule, route-without-auth is skipped", prefix: "route-", err: "lint found 1 error(s)"},
+		{name: "every rule", prefix: "", rules: []string{RULE_SERVICE_TIMEOUT, RULE_SERVICE_NO_RETRIES}},
+	}
+
+	for _, tc := range cases {
+		args := []string{"--state", state, "--output", "json"}
+		for _, rule := range tc.rules {
+			args = append(args, "--rule", rule)
+		}
+
+		err := runLint(newTestContext(t, lintFlags, args...), tc.prefix, tc.prefix == "")
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%s: got error %v", tc.name, err)
+		case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+			t.Errorf("%s: got error %v, want %s", tc.name, err, tc.err)
+		}
+	}
+}
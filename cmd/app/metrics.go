@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/common/client"
+	"github.com/xigang/kongctl/pkg/plugin/analytics_monitoring"
+)
+
+// The metrics view fetches /metrics from the admin api (exposed by the prometheus plugin),
+// and prints per-service request counts, latency percentiles and upstream health.
+
+const (
+	METRICS_RESOURCE_OBJECT = "metrics"
+)
+
+var MetricsCommand = cli.Command{
+	Name:   "metrics",
+	Usage:  "The kong prometheus metrics summary.",
+	Action: getMetrics,
+}
+
+//getMetrics print a summary of the prometheus metrics.
+func getMetrics(c *cli.Context) error {
+	samples, err := fetchMetrics()
+	if err != nil {
+		return err
+	}
+
+	services := analytics_monitoring.SummarizeServices(samples)
+
+	fmt.Printf("%-30s\t%-10s\t%-8s\t%-8s\t%-8s\t%-8s\t%-10s\t%-10s\t%-10s\n", "SERVICE", "REQUESTS", "2XX", "3XX", "4XX", "5XX", "P50(MS)", "P95(MS)", "P99(MS)")
+	for _, s := range services {
+		fmt.Printf("%-30s\t%-10.0f\t%-8.0f\t%-8.0f\t%-8.0f\t%-8.0f\t%-10s\t%-10s\t%-10s\n", s.Service, s.Requests,
+			s.Status["2xx"], s.Status["3xx"], s.Status["4xx"], s.Status["5xx"], formatLatency(s.P50), formatLatency(s.P95), formatLatency(s.P99))
+	}
+
+	health := analytics_monitoring.SummarizeTargetHealth(samples)
+	if len(health) == 0 {
+		return nil
+	}
+
+	fmt.Printf("\n%-30s\t%-30s\t%-15s\n", "UPSTREAM", "TARGET", "STATE")
+	for _, h := range health {
+		fmt.Printf("%-30s\t%-30s\t%-15s\n", h.Upstream, h.Target, h.State)
+	}
+
+	return nil
+}
+
+//fetchMetrics retrieve and parse the prometheus metrics of the admin api.
+func fetchMetrics() ([]analytics_monitoring.Sample, error) {
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	serverResponse, err := client.GatewayClient.Get(ctx, METRICS_RESOURCE_OBJECT, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer serverResponse.Body.Close()
+
+	return analytics_monitoring.ParseMetrics(serverResponse.Body)
+}
+
+func formatLatency(v float64) string {
+	if math.IsNaN(v) {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f", v)
+}
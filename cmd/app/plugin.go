@@ -7,14 +7,21 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/urfave/cli"
 
 	"github.com/xigang/kongctl/common/client"
 	"github.com/xigang/kongctl/common/tools"
+	"github.com/xigang/kongctl/pkg/plugin/analytics_monitoring"
 	"github.com/xigang/kongctl/pkg/plugin/authentication"
+	"github.com/xigang/kongctl/pkg/plugin/deployment"
 	"github.com/xigang/kongctl/pkg/plugin/logging"
+	"github.com/xigang/kongctl/pkg/plugin/security"
+	"github.com/xigang/kongctl/pkg/plugin/serverless"
+	"github.com/xigang/kongctl/pkg/plugin/traffic_control"
+	"github.com/xigang/kongctl/pkg/plugin/transformations"
 	"github.com/xigang/kongctl/pkg/plugin/utils"
 )
 
@@ -33,12 +40,14 @@ type Plugins struct {
 }
 
 type CommonPluginConfig struct {
-	ID         string    `json:"id"`
-	Name       string    `json:"name"`
-	RouteID    Route     `json:"route,omitempty"`
-	ServiceID  ServiceID `json:"service,omitempty"`
-	ConsumerID Comsumner `json:"consumer,omitempty"`
-	Enabled    bool      `json:"enabled,omitempty"`
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	RouteID    Route           `json:"route,omitempty"`
+	ServiceID  ServiceID       `json:"service,omitempty"`
+	ConsumerID Comsumner       `json:"consumer,omitempty"`
+	Enabled    bool            `json:"enabled,omitempty"`
+	Config     json.RawMessage `json:"config,omitempty"`
+	CreatedAt  int64           `json:"created_at,omitempty"`
 }
 
 type Route struct {
@@ -49,6 +58,33 @@ type Comsumner struct {
 	ID string `json:"id"`
 }
 
+var pluginSelectorFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "id",
+		Usage: "the plugin id",
+	},
+	cli.StringFlag{
+		Name:  "name",
+		Usage: "select the plugins of the name",
+	},
+	cli.StringFlag{
+		Name:  "service",
+		Usage: "select the plugins of the service id or name",
+	},
+	cli.StringFlag{
+		Name:  "route",
+		Usage: "select the plugins of the route id",
+	},
+	cli.StringFlag{
+		Name:  "consumer",
+		Usage: "select the plugins of the consumer id",
+	},
+	cli.BoolFlag{
+		Name:  "all",
+		Usage: "apply to every plugin matching the selector, e.g. kill-switch a plugin fleet-wide with --all --name",
+	},
+}
+
 var PluginResourceObjectCommand = cli.Command{
 	Name:  "plugin",
 	Usage: "The kong plugin object.",
@@ -65,6 +101,23 @@ var PluginResourceObjectCommand = cli.Command{
 			Subcommands: []cli.Command{
 				authentication.BasicAuthCommand,
 				logging.StatsDCommand,
+				traffic_control.ACLCommand,
+				traffic_control.RateLimitingCommand,
+				security.CORSCommand,
+				transformations.RequestTransformerCommand,
+				transformations.ResponseTransformerCommand,
+				analytics_monitoring.PrometheusCommand,
+				analytics_monitoring.ZipkinCommand,
+				deployment.AWSLambdaCommand,
+				serverless.PreFunctionCommand,
+				serverless.PostFunctionCommand,
+			},
+		},
+		{
+			Name:  "update",
+			Usage: "update a plugin object",
+			Subcommands: []cli.Command{
+				logging.StatsDUpdateCommand,
 			},
 		},
 		{
@@ -75,6 +128,15 @@ var PluginResourceObjectCommand = cli.Command{
 					Name:  "id",
 					Usage: "the plugin id",
 				},
+				cli.BoolFlag{
+					Name:  "extract-functions",
+					Usage: "write the lua functions of a pre-function or post-function plugin to files",
+				},
+				cli.StringFlag{
+					Name:  "output_dir",
+					Value: ".",
+					Usage: "the directory the extracted lua functions are written to",
+				},
 			},
 			Action: getPlugin,
 		},
@@ -90,6 +152,14 @@ var PluginResourceObjectCommand = cli.Command{
 					Name:  "route_id",
 					Usage: "the route object id",
 				},
+				cli.StringFlag{
+					Name:  "consumer_id",
+					Usage: "the consumer object id",
+				},
+				cli.StringFlag{
+					Name:  "name",
+					Usage: "the plugin name",
+				},
 				cli.StringFlag{
 					Name:  "size",
 					Value: "100",
@@ -98,6 +168,33 @@ var PluginResourceObjectCommand = cli.Command{
 			},
 			Action: getPlugins,
 		},
+		{
+			Name:  "effective",
+			Usage: "show which plugin instance wins for each plugin name on a route and consumer",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "route",
+					Usage: "the route id",
+				},
+				cli.StringFlag{
+					Name:  "consumer",
+					Usage: "the consumer id or username",
+				},
+			},
+			Action: getEffectivePlugins,
+		},
+		{
+			Name:   "enable",
+			Usage:  "enable plugin objects by id or by selector",
+			Flags:  pluginSelectorFlags,
+			Action: enablePlugins,
+		},
+		{
+			Name:   "disable",
+			Usage:  "disable plugin objects by id or by selector",
+			Flags:  pluginSelectorFlags,
+			Action: disablePlugins,
+		},
 		{
 			Name:  "delete",
 			Usage: "delete a plugin object",
@@ -144,11 +241,42 @@ func getPlugin(c *cli.Context) error {
 		return err
 	}
 
+	if c.Bool("extract-functions") {
+		return extractFunctions(body, c.String("output_dir"))
+	}
+
 	tools.IndentFromBody(body)
 
 	return nil
 }
 
+//extractFunctions write the lua functions of a serverless plugin to files.
+func extractFunctions(body []byte, dir string) error {
+	plugin := &CommonPluginConfig{}
+	if err := json.Unmarshal(body, plugin); err != nil {
+		return err
+	}
+
+	if plugin.Name != serverless.PLUGIN_PRE_FUNCTION && plugin.Name != serverless.PLUGIN_POST_FUNCTION {
+		return fmt.Errorf("plugin %s is %s, not a pre-function or post-function", plugin.ID, plugin.Name)
+	}
+
+	cfg := serverless.FunctionsConfig{}
+	if err := json.Unmarshal(plugin.Config, &cfg); err != nil {
+		return err
+	}
+
+	files, err := serverless.ExtractFunctions(cfg, dir, fmt.Sprintf("%s-%s", plugin.Name, plugin.ID))
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		fmt.Printf("write %s\n", f)
+	}
+	return nil
+}
+
 //getPlugins get all plugin
 func getPlugins(c *cli.Context) error {
 	name := c.String("name")
@@ -215,6 +343,10 @@ func deletePlugin(c *cli.Context) error {
 		return fmt.Errorf("plugin id is empty")
 	}
 
+	if err := confirmDestructive(c, fmt.Sprintf("delete plugin %s.", id), id, false); err != nil {
+		return err
+	}
+
 	requestURL := fmt.Sprintf("%s/%s", PLUGIN_RESOURCE_OBJECT, id)
 
 	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -233,3 +365,146 @@ func deletePlugin(c *cli.Context) error {
 
 	return nil
 }
+
+//enablePlugins enable the selected plugins.
+func enablePlugins(c *cli.Context) error {
+	return togglePlugins(c, true)
+}
+
+//disablePlugins disable the selected plugins.
+func disablePlugins(c *cli.Context) error {
+	return togglePlugins(c, false)
+}
+
+//togglePlugins flip the enabled field of the selected plugins and print what changed.
+func togglePlugins(c *cli.Context, enabled bool) error {
+	plugins, err := selectPlugins(c)
+	if err != nil {
+		return err
+	}
+
+	if !enabled {
+		for _, p := range plugins {
+			fmt.Printf("%s\t%s\t%s\n", p.ID, p.Name, pluginScope(p))
+		}
+		if err := confirmDestructive(c, fmt.Sprintf("disable these %d plugins.", len(plugins)), confirmWord("disable"), false); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("%-40s\t%-20s\t%-50s\t%-8s\t%-8s\t%-10s\n", "ID", "NAME", "SCOPE", "BEFORE", "AFTER", "RESULT")
+
+	var failed int
+	for _, p := range plugins {
+		result := "changed"
+		after := enabled
+
+		if p.Enabled == enabled {
+			result = "unchanged"
+		} else if err := patchPlugin(p.ID, map[string]interface{}{"enabled": enabled}); err != nil {
+			result = fmt.Sprintf("failed: %v", err)
+			after = p.Enabled
+			failed++
+		}
+
+		fmt.Printf("%-40s\t%-20s\t%-50s\t%-8t\t%-8t\t%-10s\n", p.ID, p.Name, pluginScope(p), p.Enabled, after, result)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to update %d of %d plugins", failed, len(plugins))
+	}
+	return nil
+}
+
+//selectPlugins return the plugin of the id, or the plugins matching the selector flags.
+func selectPlugins(c *cli.Context) ([]CommonPluginConfig, error) {
+	if id := c.String("id"); id != "" {
+		plugin := CommonPluginConfig{}
+		if err := getObject(fmt.Sprintf("%s/%s", PLUGIN_RESOURCE_OBJECT, id), &plugin); err != nil {
+			return nil, err
+		}
+		return []CommonPluginConfig{plugin}, nil
+	}
+
+	name := c.String("name")
+	service := c.String("service")
+	route := c.String("route")
+	consumer := c.String("consumer")
+
+	if name == "" && service == "" && route == "" && consumer == "" {
+		return nil, fmt.Errorf("plugin id or at least one of name, service, route, consumer must be specified")
+	}
+
+	if service != "" {
+		s := ServiceConfig{}
+		if err := getObject(fmt.Sprintf("%s/%s", SERVICE_RESOURCE_OBJECT, service), &s); err != nil {
+			return nil, err
+		}
+		service = s.ID
+	}
+
+	plugins, err := fetchAllPlugins(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []CommonPluginConfig
+	for _, p := range plugins {
+		if service != "" && p.ServiceID.ID != service {
+			continue
+		}
+		if route != "" && p.RouteID.ID != route {
+			continue
+		}
+		if consumer != "" && p.ConsumerID.ID != consumer {
+			continue
+		}
+		selected = append(selected, p)
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no plugin matches the selector")
+	}
+
+	if len(selected) > 1 && !c.Bool("all") {
+		ids := make([]string, 0, len(selected))
+		for _, p := range selected {
+			ids = append(ids, p.ID)
+		}
+		return nil, fmt.Errorf("%d plugins match the selector (%s), use --all to apply to all of them", len(selected), strings.Join(ids, ", "))
+	}
+
+	return selected, nil
+}
+
+//patchPlugin update the fields of a plugin.
+func patchPlugin(id string, fields map[string]interface{}) error {
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	serverResponse, err := client.GatewayClient.PATCH(ctx, fmt.Sprintf("%s/%s", PLUGIN_RESOURCE_OBJECT, id), nil, fields, nil)
+	if err != nil {
+		return err
+	}
+
+	return serverResponse.Body.Close()
+}
+
+//pluginScope describe the entities a plugin is applied to.
+func pluginScope(p CommonPluginConfig) string {
+	var scope []string
+	if p.ConsumerID.ID != "" {
+		scope = append(scope, "consumer "+p.ConsumerID.ID)
+	}
+	if p.RouteID.ID != "" {
+		scope = append(scope, "route "+p.RouteID.ID)
+	}
+	if p.ServiceID.ID != "" {
+		scope = append(scope, "service "+p.ServiceID.ID)
+	}
+
+	if len(scope) == 0 {
+		return "global"
+	}
+	return strings.Join(scope, ", ")
+}
@@ -0,0 +1,167 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// Kong runs a single instance of each plugin for a request. When the same plugin is configured on several entities,
+// the most specific one wins: consumer+route > consumer+service > route > service > consumer > global.
+
+//the precedence levels, the lower the more specific.
+const (
+	PRECEDENCE_CONSUMER_ROUTE = iota + 1
+	PRECEDENCE_CONSUMER_SERVICE
+	PRECEDENCE_ROUTE
+	PRECEDENCE_SERVICE
+	PRECEDENCE_CONSUMER
+	PRECEDENCE_GLOBAL
+)
+
+var precedenceNames = map[int]string{
+	PRECEDENCE_CONSUMER_ROUTE:   "consumer+route",
+	PRECEDENCE_CONSUMER_SERVICE: "consumer+service",
+	PRECEDENCE_ROUTE:            "route",
+	PRECEDENCE_SERVICE:          "service",
+	PRECEDENCE_CONSUMER:         "consumer",
+	PRECEDENCE_GLOBAL:           "global",
+}
+
+//effectivePlugin is the plugin instance that wins for a plugin name, and the instances it overrides.
+type effectivePlugin struct {
+	Winner     CommonPluginConfig
+	Precedence int
+	Overridden []CommonPluginConfig
+}
+
+//pluginPrecedence return the precedence level of a plugin for a request matching route, service and consumer,
+//or 0 when the plugin does not apply to the request.
+func pluginPrecedence(p CommonPluginConfig, routeID, serviceID, consumerID string) int {
+	if p.RouteID.ID != "" && p.RouteID.ID != routeID {
+		return 0
+	}
+	if p.ServiceID.ID != "" && p.ServiceID.ID != serviceID {
+		return 0
+	}
+	if p.ConsumerID.ID != "" && p.ConsumerID.ID != consumerID {
+		return 0
+	}
+
+	consumer := p.ConsumerID.ID != ""
+	switch {
+	case consumer && p.RouteID.ID != "":
+		return PRECEDENCE_CONSUMER_ROUTE
+	case consumer && p.ServiceID.ID != "":
+		return PRECEDENCE_CONSUMER_SERVICE
+	case p.RouteID.ID != "":
+		return PRECEDENCE_ROUTE
+	case p.ServiceID.ID != "":
+		return PRECEDENCE_SERVICE
+	case consumer:
+		return PRECEDENCE_CONSUMER
+	default:
+		return PRECEDENCE_GLOBAL
+	}
+}
+
+//resolveEffectivePlugins apply the precedence rules to the enabled plugins and return the winner of each plugin name.
+func resolveEffectivePlugins(plugins []CommonPluginConfig, routeID, serviceID, consumerID string) map[string]*effectivePlugin {
+	effective := map[string]*effectivePlugin{}
+
+	for _, p := range plugins {
+		if !p.Enabled {
+			continue
+		}
+
+		level := pluginPrecedence(p, routeID, serviceID, consumerID)
+		if level == 0 {
+			continue
+		}
+
+		e, ok := effective[p.Name]
+		if !ok {
+			effective[p.Name] = &effectivePlugin{Winner: p, Precedence: level}
+			continue
+		}
+
+		if level < e.Precedence {
+			e.Overridden = append(e.Overridden, e.Winner)
+			e.Winner, e.Precedence = p, level
+		} else {
+			e.Overridden = append(e.Overridden, p)
+		}
+	}
+
+	return effective
+}
+
+//resolveRoutePlugin pick the instance of the plugin name that applies to a route for requests
+//without a consumer, a route plugin overrides a service plugin which overrides a global one.
+func resolveRoutePlugin(plugins []CommonPluginConfig, name, routeID, serviceID string) (*CommonPluginConfig, string) {
+	e, ok := resolveEffectivePlugins(plugins, routeID, serviceID, "")[name]
+	if !ok {
+		return nil, ""
+	}
+	return &e.Winner, pluginScope(e.Winner)
+}
+
+//getEffectivePlugins print which plugin instance wins for each plugin name on a route and consumer, and why.
+func getEffectivePlugins(c *cli.Context) error {
+	routeID := c.String("route")
+	consumer := c.String("consumer")
+
+	if routeID == "" {
+		return fmt.Errorf("route id is empty")
+	}
+
+	route := &RouteConfig{}
+	if err := getObject(fmt.Sprintf("%s/%s", ROUTE_RESOURCE_OBJECT, routeID), route); err != nil {
+		return err
+	}
+
+	var consumerID string
+	if consumer != "" {
+		cm := &ConsumerConfig{}
+		if err := getObject(fmt.Sprintf("%s/%s", CONSUMER_RESOURCE_OBJECT, consumer), cm); err != nil {
+			return err
+		}
+		consumerID = cm.ID
+	}
+
+	plugins, err := fetchAllPlugins("")
+	if err != nil {
+		return err
+	}
+
+	effective := resolveEffectivePlugins(plugins, route.ID, route.Service.ID, consumerID)
+
+	names := make([]string, 0, len(effective))
+	for name := range effective {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Printf("%-20s\t%-40s\t%-18s\t%-60s\n", "NAME", "ID", "PRECEDENCE", "REASON")
+	for _, name := range names {
+		e := effective[name]
+		fmt.Printf("%-20s\t%-40s\t%-18s\t%-60s\n", name, e.Winner.ID, precedenceNames[e.Precedence], effectiveReason(e))
+	}
+
+	return nil
+}
+
+func effectiveReason(e *effectivePlugin) string {
+	if len(e.Overridden) == 0 {
+		return fmt.Sprintf("only instance, applied on %s", pluginScope(e.Winner))
+	}
+
+	overridden := make([]string, 0, len(e.Overridden))
+	for _, p := range e.Overridden {
+		overridden = append(overridden, fmt.Sprintf("%s %s", p.ID, precedenceNames[pluginPrecedence(p, p.RouteID.ID, p.ServiceID.ID, p.ConsumerID.ID)]))
+	}
+
+	return fmt.Sprintf("%s is more specific than %s", precedenceNames[e.Precedence], strings.Join(overridden, ", "))
+}
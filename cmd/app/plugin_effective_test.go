@@ -0,0 +1,89 @@
+package app
+
+import (
+	"testing"
+)
+
+func TestPluginPrecedence(t *testing.T) {
+	cases := []struct {
+		plugin CommonPluginConfig
+		want   int
+	}{
+		{CommonPluginConfig{ConsumerID: Comsumner{ID: "c1"}, RouteID: Route{ID: "r1"}}, PRECEDENCE_CONSUMER_ROUTE},
+		{CommonPluginConfig{ConsumerID: Comsumner{ID: "c1"}, ServiceID: ServiceID{ID: "s1"}}, PRECEDENCE_CONSUMER_SERVICE},
+		{CommonPluginConfig{RouteID: Route{ID: "r1"}, ServiceID: ServiceID{ID: "s1"}}, PRECEDENCE_ROUTE},
+		{CommonPluginConfig{ServiceID: ServiceID{ID: "s1"}}, PRECEDENCE_SERVICE},
+		{CommonPluginConfig{ConsumerID: Comsumner{ID: "c1"}}, PRECEDENCE_CONSUMER},
+		{CommonPluginConfig{}, PRECEDENCE_GLOBAL},
+		{CommonPluginConfig{RouteID: Route{ID: "r2"}}, 0},
+		{CommonPluginConfig{ServiceID: ServiceID{ID: "s2"}}, 0},
+		{CommonPluginConfig{ConsumerID: Comsumner{ID: "c2"}}, 0},
+	}
+
+	for _, tc := range cases {
+		if got := pluginPrecedence(tc.plugin, "r1", "s1", "c1"); got != tc.want {
+			t.Errorf("%s: got %d, want %d", pluginScope(tc.plugin), got, tc.want)
+		}
+	}
+}
+
+func TestResolveRoutePlugin(t *testing.T) {
+	plugins := []CommonPluginConfig{
+		{ID: "cors-global", Name: "cors", Enabled: true},
+		{ID: "acl-service", Name: "acl", Enabled: true, ServiceID: ServiceID{ID: "s1"}},
+		{ID: "cors-route", Name: "cors", Enabled: true, RouteID: Route{ID: "r1"}},
+		{ID: "acl-route-disabled", Name: "acl", RouteID: Route{ID: "r1"}},
+		{ID: "acl-consumer", Name: "acl", Enabled: true, ConsumerID: Comsumner{ID: "c1"}, RouteID: Route{ID: "r1"}},
+	}
+
+	cases := []struct {
+		name, route, service string
+		want, scope          string
+	}{
+		{"cors", "r1", "s1", "cors-route", "route r1"},
+		{"cors", "r2", "s1", "cors-global", "global"},
+		{"acl", "r1", "s1", "acl-service", "service s1"},
+		{"acl", "r2", "s2", "", ""},
+		{"zipkin", "r1", "s1", "", ""},
+	}
+
+	for _, tc := range cases {
+		//the map of the effective plugins is iterated in random order, resolve several times.
+		for i := 0; i < 10; i++ {
+			plugin, scope := resolveRoutePlugin(plugins, tc.name, tc.route, tc.service)
+
+			got := ""
+			if plugin != nil {
+				got = plugin.ID
+			}
+			if got != tc.want || scope != tc.scope {
+				t.Errorf("%s on %s: got %q %q, want %q %q", tc.name, tc.route, got, scope, tc.want, tc.scope)
+				break
+			}
+		}
+	}
+}
+
+func TestResolveEffectivePlugins(t *testing.T) {
+	plugins := []CommonPluginConfig{
+		{ID: "p1", Name: "rate-limiting", Enabled: true},
+		{ID: "p2", Name: "rate-limiting", Enabled: true, ServiceID: ServiceID{ID: "s1"}},
+		{ID: "p3", Name: "rate-limiting", Enabled: true, ConsumerID: Comsumner{ID: "c1"}, ServiceID: ServiceID{ID: "s1"}},
+	}
+
+	cases := []struct {
+		consumer   string
+		winner     string
+		overridden int
+	}{
+		{"c1", "p3", 2},
+		{"", "p2", 1},
+	}
+
+	for _, tc := range cases {
+		e := resolveEffectivePlugins(plugins, "r1", "s1", tc.consumer)["rate-limiting"]
+		if e == nil || e.Winner.ID != tc.winner || len(e.Overridden) != tc.overridden {
+			t.Errorf("consumer %q: got %+v, want %s overriding %d", tc.consumer, e, tc.winner, tc.overridden)
+		}
+	}
+}
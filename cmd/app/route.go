@@ -140,6 +140,7 @@ var RouteResourceObjectCommand = cli.Command{
 			},
 			Action: getRoutes,
 		},
+		routeMatchCommand,
 	},
 }
 
@@ -225,6 +226,10 @@ func deleteRoute(c *cli.Context) error {
 		return fmt.Errorf("route id is empty")
 	}
 
+	if err := confirmDestructive(c, fmt.Sprintf("delete route %s.", id), id, false); err != nil {
+		return err
+	}
+
 	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cannel()
 
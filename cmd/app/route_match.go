@@ -0,0 +1,354 @@
+package app
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+// The route match simulates the kong 0.14 router on the routes fetched from kong or read from a state file.
+// A route matches a request when all of its hosts, methods and paths conditions match, then:
+//  - the routes with more conditions are evaluated first,
+//  - then the routes with a host, then with a path, then with a method, by category bits,
+//  - regex paths are evaluated before prefix paths, by regex_priority, then prefix paths by length,
+//  - plain hosts are evaluated before wildcard hosts,
+//  - the remaining ties are resolved by the order of the routes.
+
+//the category bits of the conditions of a route.
+const (
+	ROUTE_CATEGORY_HOST   = 0x04
+	ROUTE_CATEGORY_URI    = 0x02
+	ROUTE_CATEGORY_METHOD = 0x01
+)
+
+var routeMatchCommand = cli.Command{
+	Name:  "match",
+	Usage: "show which route, service and upstream url would handle a request",
+	Flags: []cli.Flag{
+		cli.StringFlag{Name: "method", Value: "GET", Usage: "the request method"},
+		cli.StringFlag{Name: "scheme", Value: "http", Usage: "the request protocol: http, https"},
+		cli.StringFlag{Name: "host", Usage: "the request host, with an optional port"},
+		cli.StringFlag{Name: "path", Value: "/", Usage: "the request path, with an optional querystring"},
+		cli.StringSliceFlag{Name: "header", Usage: "a request header Name: value, only Host is used by the kong 0.14 router"},
+		cli.StringFlag{Name: "state", Usage: "a state file holding the services and routes, instead of fetching them from kong"},
+	},
+	Action: matchRoute,
+}
+
+//routeRequest is the simulated request.
+type routeRequest struct {
+	Scheme string
+	Method string
+	Host   string
+	Path   string
+	Query  string
+}
+
+//routeCandidate is a route matching the request, and how it matches.
+type routeCandidate struct {
+	Route       *RouteConfig
+	Index       int
+	Conditions  int
+	MatchedHost string
+	Wildcard    bool
+	MatchedPath string
+	Regex       bool
+}
+
+func matchRoute(c *cli.Context) error {
+	req := routeRequest{
+		Scheme: strings.ToLower(c.String("scheme")),
+		Method: strings.ToUpper(c.String("method")),
+		Host:   c.String("host"),
+		Path:   c.String("path"),
+	}
+
+	for _, h := range c.StringSlice("header") {
+		name, value := splitHeader(h)
+		if strings.EqualFold(name, "host") {
+			req.Host = value
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "warning: header %s is ignored, kong 0.14 routes do not match on headers\n", name)
+	}
+
+	if i := strings.Index(req.Path, "?"); i >= 0 {
+		req.Path, req.Query = req.Path[:i], req.Path[i+1:]
+	}
+	if !strings.HasPrefix(req.Path, "/") {
+		req.Path = "/" + req.Path
+	}
+
+	state, err := loadGatewayState(c.String("state"), false)
+	if err != nil {
+		return err
+	}
+
+	candidates := routeCandidates(state.Routes, req)
+	if len(candidates) == 0 {
+		return fmt.Errorf("no route matches %s %s://%s%s, kong responds 404", req.Method, req.Scheme, req.Host, req.Path)
+	}
+
+	winner := candidates[0]
+	route := winner.Route
+
+	fmt.Printf("%-15s%s\n", "ROUTE", route.ID)
+	fmt.Printf("%-15s%s\n", "MATCHED BY", winner.reason())
+
+	service := state.service(route.Service.ID)
+	if service == nil {
+		fmt.Printf("%-15s%s (not found)\n", "SERVICE", route.Service.ID)
+	} else {
+		fmt.Printf("%-15s%s %s\n", "SERVICE", service.Name, service.ID)
+		fmt.Printf("%-15s%s\n", "UPSTREAM URL", upstreamURL(winner, service, req))
+
+		host := service.Host
+		if route.PreserveHost && req.Host != "" {
+			host = req.Host
+		}
+		fmt.Printf("%-15s%s\n", "HOST HEADER", host)
+	}
+
+	if len(candidates) > 1 {
+		fmt.Printf("\n%-6s\t%-40s\t%-60s\n", "RANK", "ROUTE_ID", "MATCHED_BY")
+		for i, candidate := range candidates {
+			fmt.Printf("%-6d\t%-40s\t%-60s\n", i+1, candidate.Route.ID, candidate.reason())
+		}
+	}
+
+	return nil
+}
+
+//routeCandidates return the routes matching the request, the winner first.
+func routeCandidates(routes []RouteConfig, req routeRequest) []routeCandidate {
+	var candidates []routeCandidate
+	for i := range routes {
+		if candidate, ok := matchRouteConditions(&routes[i], req); ok {
+			candidate.Index = i
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].before(candidates[j])
+	})
+	return candidates
+}
+
+//before report whether the router evaluates a before b.
+func (a routeCandidate) before(b routeCandidate) bool {
+	if a.Conditions != b.Conditions {
+		return a.Conditions > b.Conditions
+	}
+
+	if ca, cb := routeCategory(a.Route), routeCategory(b.Route); ca != cb {
+		return ca > cb
+	}
+
+	if a.MatchedPath != "" && b.MatchedPath != "" {
+		if a.Regex != b.Regex {
+			return a.Regex
+		}
+		if a.Regex && a.Route.RegexPriority != b.Route.RegexPriority {
+			return a.Route.RegexPriority > b.Route.RegexPriority
+		}
+		if !a.Regex && len(a.MatchedPath) != len(b.MatchedPath) {
+			return len(a.MatchedPath) > len(b.MatchedPath)
+		}
+	}
+
+	if a.MatchedHost != "" && b.MatchedHost != "" && a.Wildcard != b.Wildcard {
+		return !a.Wildcard
+	}
+
+	return a.Index < b.Index
+}
+
+//routeCategory return the category bits of a route, among routes with as many conditions
+//kong 0.14 evaluates the higher categories first.
+func routeCategory(route *RouteConfig) int {
+	category := 0
+	if len(route.Hosts) > 0 {
+		category |= ROUTE_CATEGORY_HOST
+	}
+	if len(route.Paths) > 0 {
+		category |= ROUTE_CATEGORY_URI
+	}
+	if len(route.Methods) > 0 {
+		category |= ROUTE_CATEGORY_METHOD
+	}
+	return category
+}
+
+func (a routeCandidate) reason() string {
+	var reasons []string
+	if a.MatchedHost != "" {
+		kind := "host"
+		if a.Wildcard {
+			kind = "wildcard host"
+		}
+		reasons = append(reasons, fmt.Sprintf("%s %s", kind, a.MatchedHost))
+	}
+	if len(a.Route.Methods) > 0 {
+		reasons = append(reasons, fmt.Sprintf("methods %s", strings.Join(a.Route.Methods, ",")))
+	}
+	if a.MatchedPath != "" {
+		if a.Regex {
+			reasons = append(reasons, fmt.Sprintf("regex path %s (regex_priority %d)", a.MatchedPath, a.Route.RegexPriority))
+		} else {
+			reasons = append(reasons, fmt.Sprintf("prefix path %s", a.MatchedPath))
+		}
+	}
+	if len(reasons) == 0 {
+		return "no condition"
+	}
+	return strings.Join(reasons, ", ")
+}
+
+//matchRouteConditions check the protocols, hosts, methods and paths of a route against the request.
+func matchRouteConditions(route *RouteConfig, req routeRequest) (routeCandidate, bool) {
+	candidate := routeCandidate{Route: route}
+
+	if len(route.Protocols) > 0 && !containsFold(route.Protocols, req.Scheme) {
+		return candidate, false
+	}
+
+	if len(route.Hosts) > 0 {
+		candidate.Conditions++
+		for _, h := range route.Hosts {
+			wildcard := strings.Contains(h, "*")
+			if !matchHost(h, req.Host) {
+				continue
+			}
+			//prefer a plain host over a wildcard one.
+			if candidate.MatchedHost == "" || candidate.Wildcard && !wildcard {
+				candidate.MatchedHost, candidate.Wildcard = h, wildcard
+			}
+		}
+		if candidate.MatchedHost == "" {
+			return candidate, false
+		}
+	}
+
+	if len(route.Methods) > 0 {
+		candidate.Conditions++
+		if !containsFold(route.Methods, req.Method) {
+			return candidate, false
+		}
+	}
+
+	if len(route.Paths) > 0 {
+		candidate.Conditions++
+		for _, p := range route.Paths {
+			if isRegexPath(p) {
+				re, err := regexp.Compile("^" + p)
+				if err != nil || !re.MatchString(req.Path) {
+					continue
+				}
+				if !candidate.Regex {
+					candidate.MatchedPath, candidate.Regex = p, true
+				}
+				continue
+			}
+
+			if strings.HasPrefix(req.Path, p) && !candidate.Regex && len(p) > len(candidate.MatchedPath) {
+				candidate.MatchedPath = p
+			}
+		}
+		if candidate.MatchedPath == "" {
+			return candidate, false
+		}
+	}
+
+	return candidate, true
+}
+
+//matchHost match a route host, plain or with a leading or trailing wildcard, with the request host.
+//The port of the request host is ignored unless the route host has one.
+func matchHost(routeHost, reqHost string) bool {
+	routeHost, reqHost = strings.ToLower(routeHost), strings.ToLower(reqHost)
+
+	if _, _, err := net.SplitHostPort(routeHost); err != nil {
+		if h, _, err := net.SplitHostPort(reqHost); err == nil {
+			reqHost = h
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(routeHost, "*."):
+		return strings.HasSuffix(reqHost, routeHost[1:]) && len(reqHost) > len(routeHost)-1
+	case strings.HasSuffix(routeHost, ".*"):
+		return strings.HasPrefix(reqHost, routeHost[:len(routeHost)-1]) && len(reqHost) > len(routeHost)-1
+	default:
+		return routeHost == reqHost
+	}
+}
+
+var plainPathPattern = regexp.MustCompile(`^[a-zA-Z0-9.\-_~/%]*$`)
+
+//isRegexPath report whether kong treats a route path as a regex, any character outside of a plain uri makes a regex.
+func isRegexPath(path string) bool {
+	return !plainPathPattern.MatchString(path)
+}
+
+//upstreamURL build the url kong proxies the request to, applying strip_path and the service path.
+func upstreamURL(candidate routeCandidate, service *ServiceConfig, req routeRequest) string {
+	uri := req.Path
+	if candidate.Route.StripPath && candidate.MatchedPath != "" {
+		prefix := candidate.MatchedPath
+		if candidate.Regex {
+			prefix = regexp.MustCompile("^" + candidate.MatchedPath).FindString(req.Path)
+		}
+		uri = strings.TrimPrefix(req.Path, prefix)
+		if !strings.HasPrefix(uri, "/") {
+			uri = "/" + uri
+		}
+	}
+
+	if service.Path != "" && service.Path != "/" {
+		if uri == "/" {
+			uri = service.Path
+		} else {
+			uri = strings.TrimSuffix(service.Path, "/") + uri
+		}
+	}
+
+	if req.Query != "" {
+		uri += "?" + req.Query
+	}
+
+	protocol := service.Protocol
+	if protocol == "" {
+		protocol = "http"
+	}
+
+	host := service.Host
+	if service.Port != 0 {
+		host = net.JoinHostPort(service.Host, strconv.Itoa(service.Port))
+	}
+
+	return fmt.Sprintf("%s://%s%s", protocol, host, uri)
+}
+
+func splitHeader(header string) (string, string) {
+	parts := strings.SplitN(header, ":", 2)
+	if len(parts) < 2 {
+		return strings.TrimSpace(parts[0]), ""
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+}
+
+func containsFold(list []string, value string) bool {
+	for _, l := range list {
+		if strings.EqualFold(l, value) {
+			return true
+		}
+	}
+	return false
+}
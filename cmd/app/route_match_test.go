@@ -0,0 +1,179 @@
+package app
+
+import (
+	"testing"
+)
+
+func TestRouteCandidates(t *testing.T) {
+	cases := []struct {
+		name   string
+		routes []RouteConfig
+		req    routeRequest
+		want   string
+	}{
+		{
+			name: "a host route wins over a path route listed first",
+			routes: []RouteConfig{
+				{ID: "path", Paths: []string{"/"}},
+				{ID: "host", Hosts: []string{"example.com"}},
+			},
+			req:  routeRequest{Scheme: "http", Method: "GET", Host: "example.com", Path: "/orders"},
+			want: "host",
+		},
+		{
+			name: "a path route wins over a method route listed first",
+			routes: []RouteConfig{
+				{ID: "method", Methods: []string{"GET"}},
+				{ID: "path", Paths: []string{"/orders"}},
+			},
+			req:  routeRequest{Scheme: "http", Method: "GET", Path: "/orders"},
+			want: "path",
+		},
+		{
+			name: "more conditions win",
+			routes: []RouteConfig{
+				{ID: "host", Hosts: []string{"example.com"}},
+				{ID: "path-method", Paths: []string{"/orders"}, Methods: []string{"GET"}},
+			},
+			req:  routeRequest{Scheme: "http", Method: "GET", Host: "example.com", Path: "/orders"},
+			want: "path-method",
+		},
+		{
+			name: "a prefix path wins when the regex path does not match",
+			routes: []RouteConfig{
+				{ID: "prefix", Paths: []string{"/orders/items"}},
+				{ID: "regex", Paths: []string{`/orders/\d+`}},
+			},
+			req:  routeRequest{Scheme: "http", Method: "GET", Path: "/orders/items"},
+			want: "prefix",
+		},
+		{
+			name: "a regex path wins over a prefix path it matches",
+			routes: []RouteConfig{
+				{ID: "prefix", Paths: []string{"/orders"}},
+				{ID: "regex", Paths: []string{`/orders/\d+`}},
+			},
+			req:  routeRequest{Scheme: "http", Method: "GET", Path: "/orders/42"},
+			want: "regex",
+		},
+		{
+			name: "the highest regex_priority wins",
+			routes: []RouteConfig{
+				{ID: "low", Paths: []string{`/orders/\d+`}},
+				{ID: "high", Paths: []string{`/orders/[0-9]+`}, RegexPriority: 10},
+			},
+			req:  routeRequest{Scheme: "http", Method: "GET", Path: "/orders/42"},
+			want: "high",
+		},
+		{
+			name: "the longest prefix wins",
+			routes: []RouteConfig{
+				{ID: "short", Paths: []string{"/orders"}},
+				{ID: "long", Paths: []string{"/orders/items"}},
+			},
+			req:  routeRequest{Scheme: "http", Method: "GET", Path: "/orders/items/1"},
+			want: "long",
+		},
+		{
+			name: "a plain host wins over a wildcard host",
+			routes: []RouteConfig{
+				{ID: "wildcard", Hosts: []string{"*.example.com"}},
+				{ID: "plain", Hosts: []string{"api.example.com"}},
+			},
+			req:  routeRequest{Scheme: "http", Method: "GET", Host: "api.example.com:8000", Path: "/"},
+			want: "plain",
+		},
+		{
+			name: "the first route wins a tie",
+			routes: []RouteConfig{
+				{ID: "first", Paths: []string{"/orders"}},
+				{ID: "second", Paths: []string{"/orders"}},
+			},
+			req:  routeRequest{Scheme: "http", Method: "GET", Path: "/orders"},
+			want: "first",
+		},
+		{
+			name: "the protocol must match",
+			routes: []RouteConfig{
+				{ID: "https", Protocols: []string{"https"}, Paths: []string{"/orders"}},
+				{ID: "http", Protocols: []string{"http"}, Paths: []string{"/"}},
+			},
+			req:  routeRequest{Scheme: "http", Method: "GET", Path: "/orders"},
+			want: "http",
+		},
+	}
+
+	for _, tc := range cases {
+		candidates := routeCandidates(tc.routes, tc.req)
+		if len(candidates) == 0 {
+			t.Errorf("%s: no route matches", tc.name)
+			continue
+		}
+		if got := candidates[0].Route.ID; got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMatchHost(t *testing.T) {
+	cases := []struct {
+		route, req string
+		want       bool
+	}{
+		{"example.com", "example.com", true},
+		{"example.com", "EXAMPLE.com:8000", true},
+		{"example.com:8000", "example.com:9000", false},
+		{"*.example.com", "api.example.com", true},
+		{"*.example.com", "example.com", false},
+		{"example.*", "example.org", true},
+		{"example.*", "example.", false},
+	}
+
+	for _, tc := range cases {
+		if got := matchHost(tc.route, tc.req); got != tc.want {
+			t.Errorf("matchHost(%s, %s): got %v, want %v", tc.route, tc.req, got, tc.want)
+		}
+	}
+}
+
+func TestIsRegexPath(t *testing.T) {
+	cases := map[string]bool{
+		"/orders":        false,
+		"/orders/v1.0_~": false,
+		`/orders/\d+`:    true,
+		"/orders/(a|b)":  true,
+		"/orders$":       true,
+	}
+
+	for path, want := range cases {
+		if got := isRegexPath(path); got != want {
+			t.Errorf("isRegexPath(%s): got %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestUpstreamURL(t *testing.T) {
+	service := &ServiceConfig{Protocol: "http", Host: "orders.internal", Port: 8080, Path: "/api"}
+
+	cases := []struct {
+		route RouteConfig
+		req   routeRequest
+		want  string
+	}{
+		{RouteConfig{Paths: []string{"/orders"}, StripPath: true}, routeRequest{Path: "/orders/42", Query: "a=1"}, "http://orders.internal:8080/api/42?a=1"},
+		{RouteConfig{Paths: []string{"/orders"}, StripPath: true}, routeRequest{Path: "/orders"}, "http://orders.internal:8080/api"},
+		{RouteConfig{Paths: []string{"/orders"}}, routeRequest{Path: "/orders/42"}, "http://orders.internal:8080/api/orders/42"},
+		{RouteConfig{Paths: []string{`/v\d+`}, StripPath: true}, routeRequest{Path: "/v2/orders"}, "http://orders.internal:8080/api/orders"},
+	}
+
+	for _, tc := range cases {
+		candidate, ok := matchRouteConditions(&tc.route, tc.req)
+		if !ok {
+			t.Errorf("%s: the route does not match", tc.req.Path)
+			continue
+		}
+		if got := upstreamURL(candidate, service, tc.req); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.req.Path, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/common/config"
+	"github.com/xigang/kongctl/common/tools"
+)
+
+// The destructive commands ask to type the name of the resource on a terminal, --yes skips the question.
+// On a protected context every mutating command requires --yes, and then to type the context name,
+// so a command meant for a test node does not run on production by a wrong KONGCTL_CONTEXT.
+
+//the commands that change nothing on kong, by their full path. gc confirms itself when --apply.
+//A new read-only command must be added here, otherwise it is treated as a mutating command.
+var readOnlyCommands = map[string]bool{
+	"service get":             true,
+	"service list":            true,
+	"service routes":          true,
+	"route get":               true,
+	"route list":              true,
+	"route match":             true,
+	"consumer get":            true,
+	"consumer list":           true,
+	"consumer describe":       true,
+	"consumer export":         true,
+	"consumer acl list":       true,
+	"plugin avalible_plugins": true,
+	"plugin get":              true,
+	"plugin list":             true,
+	"plugin effective":        true,
+	"upstream get":            true,
+	"upstream list":           true,
+	"upstream health":         true,
+	"target list":             true,
+	"acl who-can-access":      true,
+	"cors simulate":           true,
+	"transform preview":       true,
+	"zipkin status":           true,
+	"metrics":                 true,
+	"lint":                    true,
+	"lint routes":             true,
+	"lint rules":              true,
+	"gc":                      true,
+}
+
+var yesFlag = cli.BoolFlag{Name: "yes", Usage: "do not ask for confirmation, required on a protected context"}
+
+//the context of the command, set by SetContext.
+var (
+	activeContext    string
+	protectedContext bool
+)
+
+//SetContext record the active kongctl context, a nil context is never protected.
+func SetContext(name string, ctx *config.Context) {
+	activeContext = name
+	protectedContext = ctx != nil && ctx.Protected
+}
+
+//ProtectCommands add the --yes flag to the mutating commands, and the confirmation of the protected contexts.
+func ProtectCommands(commands []cli.Command) {
+	protectCommands(commands, "")
+}
+
+func protectCommands(commands []cli.Command, parent string) {
+	for i := range commands {
+		cmd := &commands[i]
+		path := strings.TrimSpace(parent + " " + cmd.Name)
+		protectCommands(cmd.Subcommands, path)
+
+		if cmd.Action == nil || len(cmd.Subcommands) > 0 || readOnlyCommands[path] {
+			continue
+		}
+
+		if !hasFlag(cmd.Flags, yesFlag.Name) {
+			cmd.Flags = append(cmd.Flags, yesFlag)
+		}
+
+		action := cmd.Action
+		cmd.Action = func(c *cli.Context) error {
+			if err := confirmProtected(c); err != nil {
+				return err
+			}
+			return cli.HandleAction(action, c)
+		}
+	}
+}
+
+func hasFlag(flags []cli.Flag, name string) bool {
+	for _, f := range flags {
+		for _, n := range strings.Split(f.GetName(), ",") {
+			if strings.TrimSpace(n) == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//confirmProtected require --yes and the context name typed on a terminal, when the context is protected.
+func confirmProtected(c *cli.Context) error {
+	if !protectedContext {
+		return nil
+	}
+
+	if !c.Bool("yes") {
+		return fmt.Errorf("context %s is protected, run %s with --yes", activeContext, c.Command.HelpName)
+	}
+	if !tools.IsTerminal() {
+		return fmt.Errorf("context %s is protected, %s must be confirmed on a terminal", activeContext, c.Command.HelpName)
+	}
+
+	return tools.ConfirmName(fmt.Sprintf("context %s is protected, %s changes it.", activeContext, c.Command.HelpName), activeContext)
+}
+
+//confirmDestructive ask to type the name before a destructive command, unless --yes.
+func confirmDestructive(c *cli.Context, prompt, name string, required bool) error {
+	return tools.ConfirmDestructive(c.Bool("yes"), prompt, name, required)
+}
+
+//confirmWord return the word to type to confirm a command acting on many entities: the context name, or the word.
+func confirmWord(word string) string {
+	if activeContext != "" {
+		return activeContext
+	}
+	return word
+}
+
+//resourceName return the name of a resource when set, else its id.
+func resourceName(name, id string) string {
+	if name != "" {
+		return name
+	}
+	return id
+}
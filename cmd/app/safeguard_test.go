@@ -0,0 +1,120 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestProtectCommands(t *testing.T) {
+	action := func(c *cli.Context) error { return nil }
+	commands := []cli.Command{
+		{
+			Name: "consumer",
+			Subcommands: []cli.Command{
+				{Name: "get", Action: action},
+				{Name: "delete", Action: action},
+				{Name: "acl", Subcommands: []cli.Command{
+					{Name: "list", Action: action},
+					{Name: "remove", Action: action},
+				}},
+			},
+		},
+		{
+			Name: "zipkin",
+			Subcommands: []cli.Command{
+				{Name: "status", Action: action},
+			},
+		},
+		{
+			//a mutating command named like a read-only one of another resource.
+			Name: "upstream",
+			Subcommands: []cli.Command{
+				{Name: "status", Action: action},
+				{Name: "delete", Action: action, Flags: []cli.Flag{cli.BoolFlag{Name: "yes"}}},
+			},
+		},
+	}
+
+	ProtectCommands(commands)
+
+	cases := []struct {
+		command cli.Command
+		yes     bool
+	}{
+		{commands[0].Subcommands[0], false},
+		{commands[0].Subcommands[1], true},
+		{commands[0].Subcommands[2].Subcommands[0], false},
+		{commands[0].Subcommands[2].Subcommands[1], true},
+		{commands[1].Subcommands[0], false},
+		{commands[2].Subcommands[0], true},
+		{commands[2].Subcommands[1], true},
+	}
+
+	for _, tc := range cases {
+		if got := hasFlag(tc.command.Flags, "yes"); got != tc.yes {
+			t.Errorf("%s: got --yes %v, want %v", tc.command.Name, got, tc.yes)
+		}
+	}
+
+	if n := len(commands[2].Subcommands[1].Flags); n != 1 {
+		t.Errorf("upstream delete: got %d flags, want the existing --yes only", n)
+	}
+}
+
+func TestConfirmValues(t *testing.T) {
+	defer func(name string) { activeContext = name }(activeContext)
+
+	if got := resourceName("orders", "0b4a5d39-3d7c-4f4c-9c5e-0d9f3b7f8e21"); got != "orders" {
+		t.Errorf("resourceName with a name: got %s", got)
+	}
+	if got := resourceName("", "0b4a5d39"); got != "0b4a5d39" {
+		t.Errorf("resourceName without a name: got %s", got)
+	}
+
+	activeContext = ""
+	if got := confirmWord("gc"); got != "gc" {
+		t.Errorf("confirmWord without a context: got %s", got)
+	}
+	activeContext = "prod"
+	if got := confirmWord("gc"); got != "prod" {
+		t.Errorf("confirmWord with a context: got %s", got)
+	}
+}
+
+func TestReadOnlyCommandsExist(t *testing.T) {
+	paths := map[string]bool{}
+	var walk func(commands []cli.Command, parent string)
+	walk = func(commands []cli.Command, parent string) {
+		for _, cmd := range commands {
+			path := cmd.Name
+			if parent != "" {
+				path = parent + " " + cmd.Name
+			}
+			paths[path] = true
+			walk(cmd.Subcommands, path)
+		}
+	}
+
+	walk([]cli.Command{
+		ServiceResourceObjectCommand,
+		RouteResourceObjectCommand,
+		ConsumerResourceObjectCommnad,
+		PluginResourceObjectCommand,
+		UpstreamResourceObjectCommand,
+		TargetResourceObjectCommand,
+		ACLCommand,
+		CORSCommand,
+		TransformCommand,
+		MetricsCommand,
+		ZipkinCommand,
+		LintCommand,
+		GCCommand,
+	}, "")
+
+	for path := range readOnlyCommands {
+		if !paths[path] {
+			t.Errorf("read-only command %s does not exist", path)
+		}
+	}
+}
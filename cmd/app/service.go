@@ -137,6 +137,14 @@ var ServiceResourceObjectCommand = cli.Command{
 					Name:  "name",
 					Usage: "the service name",
 				},
+				cli.BoolFlag{
+					Name:  "cascade",
+					Usage: "delete the routes of the service and the plugins of the service and its routes too",
+				},
+				cli.BoolFlag{
+					Name:  "yes",
+					Usage: "do not ask for confirmation of the cascade",
+				},
 			},
 			Action: deleteService,
 		},
@@ -267,6 +275,22 @@ func deleteService(c *cli.Context) error {
 		return fmt.Errorf("name: %s id: %s is invalid", name, id)
 	}
 
+	if c.Bool("cascade") {
+		service := name
+		if service == "" {
+			service = id
+		}
+		root, err := serviceDependencies(service)
+		if err != nil {
+			return err
+		}
+		return cascadeDelete(c, root)
+	}
+
+	if err := confirmDestructive(c, fmt.Sprintf("delete service %s.", resourceName(name, id)), resourceName(name, id), false); err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
@@ -4,6 +4,21 @@ import (
 	"github.com/urfave/cli"
 )
 
+// https://docs.konghq.com/0.14.x/admin-api/#sni-objects
+
+// An SNI object represents a many-to-one mapping of hostnames to a certificate.
+
+const (
+	SNI_RESOURCE_OBJECT = "snis"
+)
+
+type SNIConfig struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	//The certificate to associate the SNI hostname with.
+	Certificate ServiceID `json:"certificate"`
+}
+
 //TODO
 var SNIResourceObjectCommand = cli.Command{
 	Name:  "snis",
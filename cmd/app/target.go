@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"sort"
 	"time"
 
 	"github.com/urfave/cli"
@@ -35,6 +36,10 @@ type TargetConfig struct {
 	Target string `json:"target"`
 	//The weight this target gets within the upstream loadbalancer (0-1000, defaults to 100). If the hostname resolves to an SRV record, the weight value will overridden by the value from the dns record.
 	Weight int `json:"weight,omitempty"`
+	//The creation time of the target entry.
+	CreatedAt float64 `json:"created_at,omitempty"`
+	//The health of the target, only returned by the upstream health endpoint.
+	Health string `json:"health,omitempty"`
 }
 
 var targetCommonFlags = []cli.Flag{
@@ -71,10 +76,28 @@ var TargetResourceObjectCommand = cli.Command{
 		{
 			Name:  "list",
 			Usage: "Lists all targets currently active on the upstream’s load balancing wheel",
-			Flags: append(targetCommonFlags, cli.StringFlag{
-				Name:  "id",
-				Usage: "the target id",
-			}),
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "upstream_id",
+					Usage: "the upstream id",
+				},
+				cli.StringFlag{
+					Name:  "name",
+					Usage: "the upstream name",
+				},
+				cli.StringFlag{
+					Name:  "id",
+					Usage: "the target id",
+				},
+				cli.StringFlag{
+					Name:  "target",
+					Usage: "The target address (ip or hostname) and port",
+				},
+				cli.BoolFlag{
+					Name:  "history",
+					Usage: "show the weight changes of the targets in chronological order, including the inactive ones",
+				},
+			},
 			Action: getTargets,
 		},
 		{
@@ -100,6 +123,38 @@ var TargetResourceObjectCommand = cli.Command{
 			},
 			Action: deleteTarget,
 		},
+		{
+			Name:   "set-healthy",
+			Usage:  "Set the target as healthy in the load balancer of the kong cluster",
+			Flags:  targetHealthFlags,
+			Action: setTargetHealthy,
+		},
+		{
+			Name:   "set-unhealthy",
+			Usage:  "Set the target as unhealthy in the load balancer of the kong cluster, to pull it out of rotation",
+			Flags:  targetHealthFlags,
+			Action: setTargetUnhealthy,
+		},
+		targetDrainCommand,
+	},
+}
+
+var targetHealthFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "upstream_id",
+		Usage: "the upstream id",
+	},
+	cli.StringFlag{
+		Name:  "name",
+		Usage: "the upstream name",
+	},
+	cli.StringFlag{
+		Name:  "id",
+		Usage: "the target id",
+	},
+	cli.StringFlag{
+		Name:  "target",
+		Usage: "The target address (ip or hostname) and port",
 	},
 }
 
@@ -149,7 +204,6 @@ func getTargets(c *cli.Context) error {
 	upstreamName := c.String("name")
 	targetID := c.String("id")
 	target := c.String("target")
-	weight := c.String("weight")
 
 	var requestURL string
 	if upstreamID != "" {
@@ -160,6 +214,10 @@ func getTargets(c *cli.Context) error {
 		return fmt.Errorf("the upstream id and name is not empty")
 	}
 
+	if c.Bool("history") {
+		return getTargetHistory(requestURL+"/all", targetID, target)
+	}
+
 	q := url.Values{}
 	if targetID != "" {
 		q.Add("id", targetID)
@@ -169,8 +227,6 @@ func getTargets(c *cli.Context) error {
 		q.Add("target", target)
 	}
 
-	q.Add("weight", weight)
-
 	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cannel()
 
@@ -211,6 +267,10 @@ func deleteTarget(c *cli.Context) error {
 		return fmt.Errorf("the target name and id is not allow empty")
 	}
 
+	if err := confirmDestructive(c, fmt.Sprintf("delete target %s.", resourceName(target, targetID)), resourceName(target, targetID), false); err != nil {
+		return err
+	}
+
 	var requestURL string
 	if upstreamID != "" && targetID != "" {
 		requestURL = fmt.Sprintf("/upstreams/%s/targets/%s", upstreamID, targetID)
@@ -237,3 +297,104 @@ func deleteTarget(c *cli.Context) error {
 	}
 	return nil
 }
+
+func setTargetHealthy(c *cli.Context) error {
+	return setTargetHealth(c, "healthy")
+}
+
+func setTargetUnhealthy(c *cli.Context) error {
+	return setTargetHealth(c, "unhealthy")
+}
+
+//setTargetHealth override the health of a target, until the health checks change it again.
+//Without active health checks, an unhealthy target stays unhealthy until it is set healthy.
+func setTargetHealth(c *cli.Context, health string) error {
+	upstream := c.String("upstream_id")
+	if upstream == "" {
+		upstream = c.String("name")
+	}
+
+	target := c.String("id")
+	if target == "" {
+		target = c.String("target")
+	}
+
+	if upstream == "" || target == "" {
+		return fmt.Errorf("upstream: %s target: %s is not allow empty", upstream, target)
+	}
+
+	requestURL := fmt.Sprintf("%s/%s/%s/%s/%s", UPSTREAM_RESOURCE_OBJECT, upstream, TARGET_RESOURCE_OBJECT, target, health)
+
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	serverResponse, err := client.GatewayClient.Post(ctx, requestURL, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	serverResponse.Body.Close()
+
+	if serverResponse.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("set target %s %s failed: %s", target, health, http.StatusText(serverResponse.StatusCode))
+	}
+
+	fmt.Printf("set target %s of upstream %s %s.\n", target, upstream, health)
+	return nil
+}
+
+//getTargetHistory print every target entry of a upstream in chronological order with its weight change,
+//kong adds a entry each time a target is created or its weight is changed.
+func getTargetHistory(requestURL, targetID, target string) error {
+	data, err := listAllObjects(requestURL, nil)
+	if err != nil {
+		return err
+	}
+
+	targets := make([]TargetConfig, 0, len(data))
+	for _, raw := range data {
+		t := TargetConfig{}
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return err
+		}
+		if (targetID != "" && t.ID != targetID) || (target != "" && t.Target != target) {
+			continue
+		}
+		targets = append(targets, t)
+	}
+
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[i].CreatedAt < targets[j].CreatedAt
+	})
+
+	weights := map[string]int{}
+	fmt.Printf("%-25s\t%-35s\t%-30s\t%-10s\t%-20s\n", "CREATED_AT", "ID", "TARGET", "WEIGHT", "CHANGE")
+	for _, t := range targets {
+		previous, seen := weights[t.Target]
+		weights[t.Target] = t.Weight
+
+		var change string
+		switch {
+		case !seen:
+			change = "added"
+		case t.Weight == 0:
+			change = fmt.Sprintf("disabled (was %d)", previous)
+		case previous == 0:
+			change = fmt.Sprintf("enabled %d", t.Weight)
+		default:
+			change = fmt.Sprintf("%d -> %d", previous, t.Weight)
+		}
+
+		fmt.Printf("%-25s\t%-35s\t%-30s\t%-10d\t%-20s\n", kongTime(t.CreatedAt).Format(time.RFC3339), t.ID, t.Target, t.Weight, change)
+	}
+
+	return nil
+}
+
+//kongTime convert a created_at, in milliseconds or in seconds with a fraction, to a time.
+func kongTime(createdAt float64) time.Time {
+	if createdAt > 1e11 {
+		createdAt /= 1000
+	}
+	sec := int64(createdAt)
+	return time.Unix(sec, int64((createdAt-float64(sec))*1e9)).Local()
+}
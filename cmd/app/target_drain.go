@@ -0,0 +1,104 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/common/client"
+)
+
+// A drain sets the weight of a target to 0, so the balancer stops sending it new requests, waits --grace
+// for the requests in flight, then deletes the target. Kong 0.14 reports no traffic per target, neither in
+// the prometheus metrics nor in the upstream health, so the drain can not tell when the traffic subsides.
+
+var targetDrainCommand = cli.Command{
+	Name:  "drain",
+	Usage: "Take a target out of the load balancer gracefully, then delete it",
+	Flags: []cli.Flag{
+		cli.StringFlag{Name: "upstream", Usage: "the upstream name or id"},
+		cli.StringFlag{Name: "target", Usage: "the target address (ip or hostname) and port"},
+		cli.DurationFlag{Name: "grace", Value: 30 * time.Second, Usage: "the time left to the requests in flight before the target is deleted"},
+		cli.BoolFlag{Name: "force", Usage: "drain even if no healthy target would remain"},
+	},
+	Action: drainTarget,
+}
+
+func drainTarget(c *cli.Context) error {
+	upstream := c.String("upstream")
+	target := c.String("target")
+
+	if upstream == "" || target == "" {
+		return fmt.Errorf("upstream: %s target: %s is not allow empty", upstream, target)
+	}
+
+	targets, err := fetchUpstreamHealth(upstream)
+	if err != nil {
+		return err
+	}
+
+	found, healthy := false, 0
+	for _, t := range targets {
+		if t.Target == target {
+			found = true
+			continue
+		}
+		if t.Weight > 0 && (t.Health == TARGET_HEALTHY || t.Health == TARGET_HEALTHCHECKS_OFF) {
+			healthy++
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("target %s is not active on upstream %s", target, upstream)
+	}
+
+	if healthy == 0 {
+		fmt.Fprintf(os.Stderr, "warning: draining %s leaves upstream %s with no healthy target\n", target, upstream)
+		if !c.Bool("force") {
+			return fmt.Errorf("drain aborted, use --force to drain anyway")
+		}
+	}
+
+	if err := confirmDestructive(c, fmt.Sprintf("drain target %s of upstream %s.", target, upstream), target, false); err != nil {
+		return err
+	}
+
+	if err := setTargetWeight(upstream, target, 0); err != nil {
+		return err
+	}
+	fmt.Printf("set the weight of target %s to 0.\n", target)
+
+	if grace := c.Duration("grace"); grace > 0 {
+		fmt.Printf("waiting %s for the requests in flight.\n", grace)
+		time.Sleep(grace)
+	}
+
+	if err := removeTarget(upstream, target); err != nil {
+		return err
+	}
+
+	fmt.Printf("drain target %s of upstream %s success.\n", target, upstream)
+	return nil
+}
+
+//removeTarget delete a target from a upstream.
+func removeTarget(upstream, target string) error {
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	requestURL := fmt.Sprintf("%s/%s/%s/%s", UPSTREAM_RESOURCE_OBJECT, upstream, TARGET_RESOURCE_OBJECT, target)
+	serverResponse, err := client.GatewayClient.Delete(ctx, requestURL, nil, nil)
+	if err != nil {
+		return err
+	}
+	serverResponse.Body.Close()
+
+	if serverResponse.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("delete target %s failed: %s", target, http.StatusText(serverResponse.StatusCode))
+	}
+	return nil
+}
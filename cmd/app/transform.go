@@ -0,0 +1,112 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/pkg/plugin/transformations"
+)
+
+// The transform preview applies a stored request-transformer or response-transformer config
+// to a sample http request or response file, offline, and prints the result.
+
+var TransformCommand = cli.Command{
+	Name:  "transform",
+	Usage: "The kong request/response transformer preview.",
+
+	Subcommands: []cli.Command{
+		{
+			Name:  "preview",
+			Usage: "apply a transformer plugin config to a sample http message",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "id",
+					Usage: "the transformer plugin id",
+				},
+				cli.StringFlag{
+					Name:  "config",
+					Usage: "a file holding the transformer plugin object, as printed by plugin get",
+				},
+				cli.StringFlag{
+					Name:  "file",
+					Usage: "the sample http request (request-transformer) or response (response-transformer) file",
+				},
+			},
+			Action: previewTransform,
+		},
+	},
+}
+
+//previewTransform print the sample http message after the transformer plugin is applied.
+func previewTransform(c *cli.Context) error {
+	id := c.String("id")
+	configFile := c.String("config")
+	file := c.String("file")
+
+	if file == "" {
+		return fmt.Errorf("the sample file is not allow empty")
+	}
+
+	plugin := &CommonPluginConfig{}
+	if configFile != "" {
+		data, err := ioutil.ReadFile(configFile)
+		if err != nil {
+			return err
+		}
+		if err = json.Unmarshal(data, plugin); err != nil {
+			return fmt.Errorf("failed to decode %s: %v", configFile, err)
+		}
+	} else if id != "" {
+		if err := getObject(fmt.Sprintf("%s/%s", PLUGIN_RESOURCE_OBJECT, id), plugin); err != nil {
+			return err
+		}
+	} else {
+		return fmt.Errorf("plugin id and config file is empty")
+	}
+
+	sample, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	switch plugin.Name {
+	case transformations.PLUGIN_REQUEST_TRANSFORMER:
+		cfg := transformations.RequestTransformerConfig{}
+		if err := json.Unmarshal(plugin.Config, &cfg); err != nil {
+			return err
+		}
+
+		req, err := transformations.ReadSampleRequest(sample)
+		if err != nil {
+			return err
+		}
+
+		if err := transformations.ApplyRequestTransformer(cfg, req); err != nil {
+			return err
+		}
+		req.Write(os.Stdout)
+	case transformations.PLUGIN_RESPONSE_TRANSFORMER:
+		cfg := transformations.ResponseTransformerConfig{}
+		if err := json.Unmarshal(plugin.Config, &cfg); err != nil {
+			return err
+		}
+
+		resp, err := transformations.ReadSampleResponse(sample)
+		if err != nil {
+			return err
+		}
+
+		if err := transformations.ApplyResponseTransformer(cfg, resp); err != nil {
+			return err
+		}
+		resp.Write(os.Stdout)
+	default:
+		return fmt.Errorf("plugin %s is %s, not a request-transformer or response-transformer", plugin.ID, plugin.Name)
+	}
+
+	return nil
+}
@@ -58,6 +58,8 @@ type HealthChecks struct {
 }
 
 type Active struct {
+	//Whether to perform active health checks using HTTP, HTTPS or just a TCP connection (kong 1.0 and later).
+	Type string `json:"type,omitempty"`
 	//Socket timeout for active health checks (in seconds).
 	Timeout int `json:"timeout,omitempty"`
 	//Number of targets to check concurrently in active health checks.
@@ -72,33 +74,53 @@ type Active struct {
 
 type Passive struct {
 	//Health checks
-	Healthy Healthy `json:"healthy,omitempty"`
+	Healthy PassiveHealthy `json:"healthy,omitempty"`
 	//Unhealthy checks
-	Unhealthy Unhealthy `json:"unhealthy,omitempty"`
+	Unhealthy PassiveUnhealthy `json:"unhealthy,omitempty"`
 }
 
+//The intervals and counters are always sent, 0 disables a probe or a counter.
 type Healthy struct {
 	//Interval between active health checks for healthy targets (in seconds). A value of zero indicates that active probes for healthy targets should not be performed.
-	Interval int `json:"interval,omitempty"`
+	Interval int `json:"interval"`
 	//An array of HTTP statuses to consider a success, indicating healthiness, when returned by a probe in active health checks.
 	HTTPStatuses []int `json:"http_statuses,omitempty"`
 	//Number of successes in active probes (as defined by healthchecks.active.healthy.http_statuses) to consider a target healthy.
-	Successes int `json:"successes,omitempty"`
+	Successes int `json:"successes"`
 }
 
 type Unhealthy struct {
 	//Interval between active health checks for unhealthy targets (in seconds). A value of zero indicates that active probes for unhealthy targets should not be performed.
-	Interval int `json:"interval,omitempty"`
+	Interval int `json:"interval"`
 	//An array of HTTP statuses to consider a failure, indicating unhealthiness, when returned by a probe in active health checks.
 	HTTPStatuses []int `json:"http_statuses,omitempty"`
 	//Number of TCP failures in active probes to consider a target unhealthy.
-	TCPFailures int `json:"tcp_failures,omitempty"`
+	TCPFailures int `json:"tcp_failures"`
 	//Number of timeouts in active probes to consider a target unhealthy.
-	Timeouts int `json:"timeouts,omitempty"`
+	Timeouts int `json:"timeouts"`
 	//Number of HTTP failures in active probes (as defined by healthchecks.active.unhealthy.http_statuses) to consider a target unhealthy.
 	HTTPFailures int `json:"http_failures"`
 }
 
+//The passive health checks observe the proxied traffic, they have no probe interval.
+type PassiveHealthy struct {
+	//An array of HTTP statuses which represent healthiness when produced by proxied traffic.
+	HTTPStatuses []int `json:"http_statuses,omitempty"`
+	//Number of successes in proxied traffic to consider a target healthy.
+	Successes int `json:"successes"`
+}
+
+type PassiveUnhealthy struct {
+	//An array of HTTP statuses which represent unhealthiness when produced by proxied traffic.
+	HTTPStatuses []int `json:"http_statuses,omitempty"`
+	//Number of TCP failures in proxied traffic to consider a target unhealthy.
+	TCPFailures int `json:"tcp_failures"`
+	//Number of timeouts in proxied traffic to consider a target unhealthy.
+	Timeouts int `json:"timeouts"`
+	//Number of HTTP failures in proxied traffic to consider a target unhealthy.
+	HTTPFailures int `json:"http_failures"`
+}
+
 var upstreamCommonFlags = []cli.Flag{
 	cli.StringFlag{Name: "name", Usage: "This is a hostname, which must be equal to the host of a Service."},
 	cli.IntFlag{Name: "slots", Value: 1000, Usage: "The number of slots in the loadbalancer algorithm (10-65536)"},
@@ -108,21 +130,29 @@ var upstreamCommonFlags = []cli.Flag{
 	cli.StringFlag{Name: "hash_fallback_header", Usage: "The header name to take the value from as hash input (only required when hash_fallback is set to header)."},
 	cli.StringFlag{Name: "hash_on_cookie", Usage: "The cookie name to take the value from as hash input (only required when hash_on or hash_fallback is set to cookie). If the specified cookie is not in the request, Kong will generate a value and set the cookie in the response."},
 	cli.StringFlag{Name: "hash_on_cookie_path", Value: "/", Usage: "The cookie path to set in the response headers (only required when hash_on or hash_fallback is set to cookie)"},
-	cli.IntFlag{Name: "healthchecks_active_timout", Usage: "Socket timeout for active health checks (in seconds)."},
+}
+
+var upstreamHealthcheckFlags = []cli.Flag{
+	cli.StringFlag{Name: "healthchecks_active_type", Usage: "Whether to perform active health checks using HTTP, HTTPS or just a TCP connection: http, https, tcp (kong 1.0 and later)."},
+	cli.IntFlag{Name: "healthchecks_active_timeout, healthchecks_active_timout", Usage: "Socket timeout for active health checks (in seconds)."},
 	cli.IntFlag{Name: "healthchecks_active_concurrency", Usage: "Number of targets to check concurrently in active health checks."},
 	cli.StringFlag{Name: "healthchecks_active_http_path", Usage: "Path to use in GET HTTP request to run as a probe on active health checks."},
 	cli.IntFlag{Name: "healthchecks_active_healthy_interval", Usage: "Interval between active health checks for healthy targets (in seconds). A value of zero indicates that active probes for healthy targets should not be performed."},
 	cli.IntSliceFlag{Name: "healthchecks_active_healthy_http_statuses", Usage: "An array of HTTP statuses to consider a success, indicating healthiness, when returned by a probe in active health checks."},
+	cli.IntFlag{Name: "healthchecks_active_healthy_successes", Usage: "Number of successes in active probes (as defined by healthchecks.active.healthy.http_statuses) to consider a target healthy."},
 	cli.IntFlag{Name: "healthchecks_active_unhealthy_interval", Usage: "Interval between active health checks for unhealthy targets (in seconds). "},
 	cli.IntSliceFlag{Name: "healthchecks_active_unhealthy_http_statuses", Usage: "An array of HTTP statuses to consider a failure, indicating unhealthiness, when returned by a probe in active health checks."},
 	cli.IntFlag{Name: "healthchecks_active_unhealthy_tcp_failures", Usage: "Number of TCP failures in active probes to consider a target unhealthy."},
 	cli.IntFlag{Name: "healthchecks_active_unhealthy_timeouts", Usage: "Number of timeouts in active probes to consider a target unhealthy."},
+	cli.IntFlag{Name: "healthchecks_active_unhealthy_http_failures", Usage: "Number of HTTP failures in active probes (as defined by healthchecks.active.unhealthy.http_statuses) to consider a target unhealthy."},
 	cli.IntSliceFlag{Name: "healthchecks_passive_healthy_http_statuses", Usage: "An array of HTTP statuses which represent healthiness when produced by proxied traffic, as observed by passive health checks."},
 	cli.IntFlag{Name: "healthchecks_passive_healthy_successes", Usage: "Number of successes in proxied traffic (as defined by healthchecks.passive.healthy.http_statuses) to consider a target healthy, as observed by passive health checks."},
 	cli.IntSliceFlag{Name: "healthchecks_passive_unhealthy_http_statuses", Usage: "An array of HTTP statuses which represent unhealthiness when produced by proxied traffic, as observed by passive health checks."},
 	cli.IntFlag{Name: "healthchecks_passive_unhealthy_tcp_failures", Usage: "Number of TCP failures in proxied traffic to consider a target unhealthy, as observed by passive health checks."},
 	cli.IntFlag{Name: "healthchecks_passive_unhealthy_timeouts", Usage: "Number of timeouts in proxied traffic to consider a target unhealthy, as observed by passive health checks."},
 	cli.IntFlag{Name: "healthchecks_passive_unhealthy_http_failures", Usage: "Number of HTTP failures in proxied traffic (as defined by healthchecks.passive.unhealthy.http_statuses) to consider a target unhealthy, as observed by passive health checks."},
+	cli.StringFlag{Name: "healthchecks-profile", Usage: "A named health checks profile, built-in (strict-http, tcp-only) or from the kongctl config"},
+	cli.StringFlag{Name: "healthchecks-file", Usage: "A json file holding the healthchecks field of the upstream"},
 }
 
 var UpstreamResourceObjectCommand = cli.Command{
@@ -133,7 +163,7 @@ var UpstreamResourceObjectCommand = cli.Command{
 		{
 			Name:   "create",
 			Usage:  "create upstream object",
-			Flags:  upstreamCommonFlags,
+			Flags:  append(upstreamCommonFlags, upstreamHealthcheckFlags...),
 			Action: createUpstream,
 		},
 		{
@@ -161,9 +191,29 @@ var UpstreamResourceObjectCommand = cli.Command{
 			Flags: []cli.Flag{
 				cli.StringFlag{Name: "name", Usage: "the upstream name"},
 				cli.StringFlag{Name: "id", Usage: "the upstream id"},
+				cli.BoolFlag{Name: "cascade", Usage: "show the targets that kong deletes with the upstream, and confirm"},
+				cli.BoolFlag{Name: "yes", Usage: "do not ask for confirmation of the cascade"},
 			},
 			Action: deleteUpstream,
 		},
+		{
+			Name:      "health",
+			Usage:     "show the health of the targets of a upstream",
+			ArgsUsage: "<name>",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "name", Usage: "the upstream name or id"},
+			},
+			Action: getUpstreamHealth,
+		},
+		{
+			Name:  "update-healthchecks",
+			Usage: "update the health checks of a upstream from a profile, a file or flags",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{Name: "name", Usage: "the upstream name or id"},
+			}, upstreamHealthcheckFlags...),
+			Action: updateUpstreamHealthChecks,
+		},
+		upstreamShiftCommand,
 	},
 }
 
@@ -182,37 +232,11 @@ func createUpstream(c *cli.Context) error {
 		HashFallbackHeader: c.String("hash_fallback_header"),
 		HashOnCookie:       c.String("hash_on_cookie"),
 		HashOnCookiePath:   c.String("hash_on_cookie_path"),
-		HealthChecks: HealthChecks{
-			Active: Active{
-				Timeout:     c.Int("timeout"),
-				Concurrency: c.Int("concurrency"),
-				HTTPPath:    c.String("http_path"),
-				Healthy: Healthy{
-					Interval:     c.Int("interval"),
-					HTTPStatuses: c.IntSlice("http_statuses"),
-					Successes:    c.Int("successes"),
-				},
-				Unhealthy: Unhealthy{
-					Interval:     c.Int("interval"),
-					HTTPFailures: c.Int("http_failures"),
-					HTTPStatuses: c.IntSlice("http_statuses"),
-					Timeouts:     c.Int("timeouts"),
-					TCPFailures:  c.Int("tcp_failures"),
-				},
-			},
-			Passive: Passive{
-				Healthy: Healthy{
-					HTTPStatuses: c.IntSlice("http_statuses"),
-					Successes:    c.Int("successes"),
-				},
-				Unhealthy: Unhealthy{
-					HTTPStatuses: c.IntSlice("http_statuses"),
-					TCPFailures:  c.Int("tcp_failures"),
-					Timeouts:     c.Int("timeouts"),
-					HTTPFailures: c.Int("http_failures"),
-				},
-			},
-		},
+	}
+
+	var err error
+	if cfg.HealthChecks, err = buildHealthChecks(c, HealthChecks{}); err != nil {
+		return err
 	}
 
 	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -319,6 +343,22 @@ func deleteUpstream(c *cli.Context) error {
 		return fmt.Errorf("the upstream name and id is not allow empty")
 	}
 
+	if c.Bool("cascade") {
+		upstream := name
+		if upstream == "" {
+			upstream = id
+		}
+		root, err := upstreamDependencies(upstream)
+		if err != nil {
+			return err
+		}
+		return cascadeDelete(c, root)
+	}
+
+	if err := confirmDestructive(c, fmt.Sprintf("delete upstream %s.", resourceName(name, id)), resourceName(name, id), false); err != nil {
+		return err
+	}
+
 	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cannel()
 
@@ -334,3 +374,45 @@ func deleteUpstream(c *cli.Context) error {
 	}
 	return nil
 }
+
+//getUpstreamHealth print the weight and health of every target of a upstream, as seen by the kong node.
+func getUpstreamHealth(c *cli.Context) error {
+	name := c.String("name")
+	if name == "" {
+		name = c.Args().First()
+	}
+	if name == "" {
+		return fmt.Errorf("the upstream name is not allow empty")
+	}
+
+	targets, err := fetchUpstreamHealth(name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-35s\t%-30s\t%-10s\t%-20s\n", "ID", "TARGET", "WEIGHT", "HEALTH")
+	for _, t := range targets {
+		fmt.Printf("%-35s\t%-30s\t%-10d\t%-20s\n", t.ID, t.Target, t.Weight, t.Health)
+	}
+
+	return nil
+}
+
+//fetchUpstreamHealth return the targets of a upstream with their health: HEALTHY, UNHEALTHY, HEALTHCHECKS_OFF or DNS_ERROR.
+func fetchUpstreamHealth(name string) ([]TargetConfig, error) {
+	data, err := listAllObjects(fmt.Sprintf("%s/%s/health", UPSTREAM_RESOURCE_OBJECT, name), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]TargetConfig, 0, len(data))
+	for _, raw := range data {
+		t := TargetConfig{}
+		if err := json.Unmarshal(raw, &t); err != nil {
+			return nil, err
+		}
+		targets = append(targets, t)
+	}
+
+	return targets, nil
+}
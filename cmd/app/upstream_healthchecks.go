@@ -0,0 +1,212 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/common/client"
+	"github.com/xigang/kongctl/common/config"
+	"github.com/xigang/kongctl/common/tools"
+)
+
+// The health checks of a upstream are built in layers: a named profile, then a --healthchecks-file,
+// then the healthchecks_* flags given on the command line, each one overriding the fields it sets.
+
+//builtinHealthcheckProfiles are available without config, a profile of the same name in the config overrides them.
+var builtinHealthcheckProfiles = map[string]HealthChecks{
+	//probe the http_path actively and eject a target on the first failure.
+	"strict-http": {
+		Active: Active{
+			Timeout:     1,
+			Concurrency: 10,
+			HTTPPath:    "/",
+			Healthy: Healthy{
+				Interval:     5,
+				HTTPStatuses: []int{200, 302},
+				Successes:    2,
+			},
+			Unhealthy: Unhealthy{
+				Interval:     5,
+				HTTPStatuses: []int{429, 404, 500, 501, 502, 503, 504, 505},
+				TCPFailures:  1,
+				Timeouts:     1,
+				HTTPFailures: 1,
+			},
+		},
+		Passive: Passive{
+			Healthy: PassiveHealthy{
+				Successes: 5,
+			},
+			Unhealthy: PassiveUnhealthy{
+				TCPFailures:  1,
+				Timeouts:     1,
+				HTTPFailures: 3,
+			},
+		},
+	},
+	//probe the tcp port actively, for targets that do not speak http. The active type needs kong 1.0 or later.
+	"tcp-only": {
+		Active: Active{
+			Type:        "tcp",
+			Timeout:     1,
+			Concurrency: 10,
+			Healthy: Healthy{
+				Interval:  5,
+				Successes: 2,
+			},
+			Unhealthy: Unhealthy{
+				Interval:    5,
+				TCPFailures: 2,
+				Timeouts:    3,
+			},
+		},
+		Passive: Passive{
+			Unhealthy: PassiveUnhealthy{
+				TCPFailures: 2,
+				Timeouts:    3,
+			},
+		},
+	},
+}
+
+//buildHealthChecks apply the profile, the file and the flags of the command on the base health checks.
+func buildHealthChecks(c *cli.Context, base HealthChecks) (HealthChecks, error) {
+	hc := base
+
+	if profile := c.String("healthchecks-profile"); profile != "" {
+		p, err := healthcheckProfile(profile)
+		if err != nil {
+			return hc, err
+		}
+		hc = p
+	}
+
+	if file := c.String("healthchecks-file"); file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return hc, err
+		}
+		//unmarshal on the profile, so the file only overrides the fields it holds.
+		if err := json.Unmarshal(data, &hc); err != nil {
+			return hc, fmt.Errorf("%s: %v", file, err)
+		}
+	}
+
+	ints := map[string]*int{
+		"healthchecks_active_timeout":                  &hc.Active.Timeout,
+		"healthchecks_active_concurrency":              &hc.Active.Concurrency,
+		"healthchecks_active_healthy_interval":         &hc.Active.Healthy.Interval,
+		"healthchecks_active_healthy_successes":        &hc.Active.Healthy.Successes,
+		"healthchecks_active_unhealthy_interval":       &hc.Active.Unhealthy.Interval,
+		"healthchecks_active_unhealthy_tcp_failures":   &hc.Active.Unhealthy.TCPFailures,
+		"healthchecks_active_unhealthy_timeouts":       &hc.Active.Unhealthy.Timeouts,
+		"healthchecks_active_unhealthy_http_failures":  &hc.Active.Unhealthy.HTTPFailures,
+		"healthchecks_passive_healthy_successes":       &hc.Passive.Healthy.Successes,
+		"healthchecks_passive_unhealthy_tcp_failures":  &hc.Passive.Unhealthy.TCPFailures,
+		"healthchecks_passive_unhealthy_timeouts":      &hc.Passive.Unhealthy.Timeouts,
+		"healthchecks_passive_unhealthy_http_failures": &hc.Passive.Unhealthy.HTTPFailures,
+	}
+	for name, v := range ints {
+		if c.IsSet(name) {
+			*v = c.Int(name)
+		}
+	}
+
+	slices := map[string]*[]int{
+		"healthchecks_active_healthy_http_statuses":    &hc.Active.Healthy.HTTPStatuses,
+		"healthchecks_active_unhealthy_http_statuses":  &hc.Active.Unhealthy.HTTPStatuses,
+		"healthchecks_passive_healthy_http_statuses":   &hc.Passive.Healthy.HTTPStatuses,
+		"healthchecks_passive_unhealthy_http_statuses": &hc.Passive.Unhealthy.HTTPStatuses,
+	}
+	for name, v := range slices {
+		if c.IsSet(name) {
+			*v = c.IntSlice(name)
+		}
+	}
+
+	if c.IsSet("healthchecks_active_type") {
+		hc.Active.Type = c.String("healthchecks_active_type")
+	}
+
+	if c.IsSet("healthchecks_active_http_path") {
+		hc.Active.HTTPPath = c.String("healthchecks_active_http_path")
+	}
+
+	return hc, nil
+}
+
+//healthcheckProfile return a profile of the config, or a built-in one.
+func healthcheckProfile(name string) (HealthChecks, error) {
+	hc := HealthChecks{}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return hc, err
+	}
+
+	if raw, ok := cfg.HealthcheckProfiles[name]; ok {
+		if err := json.Unmarshal(raw, &hc); err != nil {
+			return hc, fmt.Errorf("healthcheck profile %s of %s: %v", name, config.Path(), err)
+		}
+		return hc, nil
+	}
+
+	if p, ok := builtinHealthcheckProfiles[name]; ok {
+		return p, nil
+	}
+
+	names := make([]string, 0, len(builtinHealthcheckProfiles)+len(cfg.HealthcheckProfiles))
+	for n := range builtinHealthcheckProfiles {
+		names = append(names, n)
+	}
+	for n := range cfg.HealthcheckProfiles {
+		if _, ok := builtinHealthcheckProfiles[n]; !ok {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+
+	return hc, fmt.Errorf("healthcheck profile %s is not found, available profiles are %s", name, strings.Join(names, ", "))
+}
+
+//updateUpstreamHealthChecks apply a profile, a file or flags on the current health checks of a upstream.
+func updateUpstreamHealthChecks(c *cli.Context) error {
+	name := c.String("name")
+	if name == "" {
+		return fmt.Errorf("the upstream name is not allow empty")
+	}
+
+	upstream := &UpstreamConfig{}
+	if err := getObject(fmt.Sprintf("%s/%s", UPSTREAM_RESOURCE_OBJECT, name), upstream); err != nil {
+		return err
+	}
+
+	hc, err := buildHealthChecks(c, upstream.HealthChecks)
+	if err != nil {
+		return err
+	}
+
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	requestURL := fmt.Sprintf("%s/%s", UPSTREAM_RESOURCE_OBJECT, name)
+	serverResponse, err := client.GatewayClient.PATCH(ctx, requestURL, nil, map[string]interface{}{"healthchecks": hc}, nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := ioutil.ReadAll(serverResponse.Body)
+	if err != nil {
+		return err
+	}
+
+	tools.IndentFromBody(body)
+	return nil
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBuildHealthChecks(t *testing.T) {
+	defer os.Setenv("KONGCTL_HOME", os.Getenv("KONGCTL_HOME"))
+	os.Setenv("KONGCTL_HOME", os.TempDir()+"/kongctl-no-config")
+
+	cases := []struct {
+		name    string
+		args    []string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "a zero interval disables the active probes of a profile",
+			args: []string{"--healthchecks-profile", "strict-http", "--healthchecks_active_healthy_interval", "0", "--healthchecks_active_unhealthy_interval", "0"},
+			want: []string{`"healthy":{"interval":0,"http_statuses":[200,302],"successes":2}`, `"http_path":"/"`},
+		},
+		{
+			name:    "passive checks have no interval",
+			args:    []string{"--healthchecks-profile", "strict-http"},
+			want:    []string{`"passive":{"healthy":{"successes":5},"unhealthy":{"tcp_failures":1,"timeouts":1,"http_failures":3}}`},
+			notWant: []string{`"passive":{"healthy":{"interval"`},
+		},
+		{
+			name:    "tcp-only probes the tcp port",
+			args:    []string{"--healthchecks-profile", "tcp-only"},
+			want:    []string{`"active":{"type":"tcp","timeout":1,"concurrency":10,"healthy":{"interval":5,"successes":2}`},
+			notWant: []string{`"http_path"`},
+		},
+		{
+			name: "the active type flag overrides the profile",
+			args: []string{"--healthchecks-profile", "tcp-only", "--healthchecks_active_type", "https"},
+			want: []string{`"type":"https"`},
+		},
+		{
+			name: "flags override the profile",
+			args: []string{"--healthchecks-profile", "strict-http", "--healthchecks_active_timeout", "3"},
+			want: []string{`"timeout":3`},
+		},
+	}
+
+	for _, tc := range cases {
+		c := newTestContext(t, upstreamHealthcheckFlags, tc.args...)
+		hc, err := buildHealthChecks(c, HealthChecks{})
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+
+		data, _ := json.Marshal(hc)
+		for _, want := range tc.want {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("%s: %s does not contain %s", tc.name, data, want)
+			}
+		}
+		for _, notWant := range tc.notWant {
+			if strings.Contains(string(data), notWant) {
+				t.Errorf("%s: %s contains %s", tc.name, data, notWant)
+			}
+		}
+	}
+}
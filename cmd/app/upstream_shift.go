@@ -0,0 +1,215 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/common/client"
+)
+
+// Kong keeps the history of the targets of a upstream, adding a target that already exists
+// replaces its weight, and a weight of 0 disables it. A shift moves the traffic from one target
+// to another in steps by adding target entries, and watches the health of the new target between steps.
+
+const (
+	TARGET_HEALTHY          = "HEALTHY"
+	TARGET_UNHEALTHY        = "UNHEALTHY"
+	TARGET_HEALTHCHECKS_OFF = "HEALTHCHECKS_OFF"
+	TARGET_DNS_ERROR        = "DNS_ERROR"
+)
+
+var upstreamShiftCommand = cli.Command{
+	Name:  "shift",
+	Usage: "shift the traffic of a upstream from a target to another in steps, rolling back if the new target is unhealthy",
+	Flags: []cli.Flag{
+		cli.StringFlag{Name: "name", Usage: "the upstream name or id"},
+		cli.StringFlag{Name: "from", Usage: "the current target, host:port"},
+		cli.StringFlag{Name: "to", Usage: "the new target, host:port"},
+		cli.StringFlag{Name: "steps", Value: "10,25,50,100", Usage: "the percentages of traffic sent to the new target at each step"},
+		cli.DurationFlag{Name: "interval", Value: 2 * time.Minute, Usage: "the time to watch the health of the new target after each step"},
+		cli.IntFlag{Name: "weight", Usage: "the total weight shared by both targets, by default the current weight of the from target"},
+	},
+	Action: shiftUpstream,
+}
+
+//shiftUpstream re-weight the from and to targets step by step.
+func shiftUpstream(c *cli.Context) error {
+	name := c.String("name")
+	from := c.String("from")
+	to := c.String("to")
+	interval := c.Duration("interval")
+
+	if name == "" || from == "" || to == "" {
+		return fmt.Errorf("upstream: %s from: %s to: %s is not allow empty", name, from, to)
+	}
+	if from == to {
+		return fmt.Errorf("the from and to targets are the same")
+	}
+
+	steps, err := parseShiftSteps(c.String("steps"))
+	if err != nil {
+		return err
+	}
+
+	targets, err := fetchUpstreamHealth(name)
+	if err != nil {
+		return err
+	}
+
+	//the to target may be active already, the rollback restores its weight.
+	var current *TargetConfig
+	previous := 0
+	for i := range targets {
+		switch targets[i].Target {
+		case from:
+			current = &targets[i]
+		case to:
+			previous = targets[i].Weight
+		}
+	}
+	if current == nil {
+		return fmt.Errorf("target %s is not active on upstream %s", from, name)
+	}
+
+	total := c.Int("weight")
+	if total == 0 {
+		total = current.Weight
+	}
+	if total <= 0 || total > 1000 {
+		return fmt.Errorf("weight %d is invalid, the total weight must be between 1 and 1000", total)
+	}
+
+	rollback := func(reason string) error {
+		fmt.Printf("rolling back: %s\n", reason)
+		if err := setTargetWeight(name, from, current.Weight); err != nil {
+			return fmt.Errorf("%s, rollback failed to restore %s: %v", reason, from, err)
+		}
+		if err := setTargetWeight(name, to, previous); err != nil {
+			return fmt.Errorf("%s, rollback failed to restore %s: %v", reason, to, err)
+		}
+		return fmt.Errorf("shift aborted and rolled back: %s", reason)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	for i, percent := range steps {
+		toWeight, fromWeight := shiftWeights(total, percent)
+
+		fmt.Printf("step %d/%d: %s %d%% weight %d, %s weight %d\n", i+1, len(steps), to, percent, toWeight, from, fromWeight)
+
+		//raise the new target first, so the upstream never loses capacity.
+		if err := setTargetWeight(name, to, toWeight); err != nil {
+			return rollback(err.Error())
+		}
+		if err := setTargetWeight(name, from, fromWeight); err != nil {
+			return rollback(err.Error())
+		}
+
+		watch := interval
+		if i == len(steps)-1 {
+			watch = 0
+		}
+		if reason := watchTargetHealth(name, to, watch, interrupt); reason != "" {
+			return rollback(reason)
+		}
+	}
+
+	fmt.Printf("shift of upstream %s from %s to %s done.\n", name, from, to)
+	return nil
+}
+
+//watchTargetHealth poll the health of a target for a duration, at least once, and return why it must be rolled back,
+//or an empty string.
+func watchTargetHealth(upstream, target string, d time.Duration, interrupt chan os.Signal) string {
+	poll := 10 * time.Second
+	if d < poll {
+		poll = d
+	}
+	deadline := time.Now().Add(d)
+	warned := false
+
+	for {
+		targets, err := fetchUpstreamHealth(upstream)
+		if err != nil {
+			return fmt.Sprintf("failed to get the health of upstream %s: %v", upstream, err)
+		}
+
+		for _, t := range targets {
+			if t.Target != target {
+				continue
+			}
+			switch t.Health {
+			case TARGET_UNHEALTHY, TARGET_DNS_ERROR:
+				return fmt.Sprintf("target %s is %s", target, t.Health)
+			case TARGET_HEALTHCHECKS_OFF:
+				if !warned {
+					fmt.Printf("warning: health checks are off on upstream %s, the health of %s is not watched\n", upstream, target)
+					warned = true
+				}
+			}
+		}
+
+		if !time.Now().Before(deadline) {
+			return ""
+		}
+
+		select {
+		case <-interrupt:
+			return "interrupted"
+		case <-time.After(poll):
+		}
+	}
+}
+
+//setTargetWeight add a target entry with a weight, a weight of 0 disables the target.
+func setTargetWeight(upstream, target string, weight int) error {
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	//not a TargetConfig, its weight is omitted when 0.
+	cfg := map[string]interface{}{"target": target, "weight": weight}
+
+	requestURL := fmt.Sprintf("%s/%s/%s", UPSTREAM_RESOURCE_OBJECT, upstream, TARGET_RESOURCE_OBJECT)
+	serverResponse, err := client.GatewayClient.Post(ctx, requestURL, nil, cfg, nil)
+	if err != nil {
+		return fmt.Errorf("failed to set the weight of %s to %d: %v", target, weight, err)
+	}
+	serverResponse.Body.Close()
+
+	return nil
+}
+
+//shiftWeights split the total weight for a step. The to target gets at least 1, a weight truncated to 0
+//would disable it and the step would send it no traffic.
+func shiftWeights(total, percent int) (int, int) {
+	toWeight := total * percent / 100
+	if toWeight == 0 {
+		toWeight = 1
+	}
+	return toWeight, total - toWeight
+}
+
+//parseShiftSteps parse increasing percentages between 1 and 100.
+func parseShiftSteps(value string) ([]int, error) {
+	var steps []int
+	for _, s := range strings.Split(value, ",") {
+		percent, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || percent < 1 || percent > 100 {
+			return nil, fmt.Errorf("step %q is invalid, steps are percentages between 1 and 100", s)
+		}
+		if len(steps) > 0 && percent <= steps[len(steps)-1] {
+			return nil, fmt.Errorf("steps %s must be increasing", value)
+		}
+		steps = append(steps, percent)
+	}
+	return steps, nil
+}
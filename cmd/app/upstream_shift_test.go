@@ -0,0 +1,88 @@
+package app
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseShiftSteps(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    []int
+		wantErr bool
+	}{
+		{value: "10,25,50,100", want: []int{10, 25, 50, 100}},
+		{value: " 50 , 100", want: []int{50, 100}},
+		{value: "100", want: []int{100}},
+		{value: "0,100", wantErr: true},
+		{value: "50,101", wantErr: true},
+		{value: "50,25", wantErr: true},
+		{value: "50,50", wantErr: true},
+		{value: "10,x", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		got, err := parseShiftSteps(tc.value)
+		if (err != nil) != tc.wantErr || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("parseShiftSteps(%q): got %v, %v", tc.value, got, err)
+		}
+	}
+}
+
+func TestShiftWeights(t *testing.T) {
+	cases := []struct {
+		total, percent int
+		to, from       int
+	}{
+		{total: 100, percent: 10, to: 10, from: 90},
+		{total: 100, percent: 100, to: 100, from: 0},
+		//a truncated 0 would leave the to target disabled.
+		{total: 5, percent: 10, to: 1, from: 4},
+		{total: 1, percent: 50, to: 1, from: 0},
+		{total: 7, percent: 25, to: 1, from: 6},
+		{total: 7, percent: 50, to: 3, from: 4},
+	}
+
+	for _, tc := range cases {
+		to, from := shiftWeights(tc.total, tc.percent)
+		if to != tc.to || from != tc.from {
+			t.Errorf("shiftWeights(%d, %d): got %d, %d, want %d, %d", tc.total, tc.percent, to, from, tc.to, tc.from)
+		}
+	}
+}
+
+func TestShiftUpstreamRollback(t *testing.T) {
+	requests, restore := recordKong(t, map[string]fakeResponse{
+		"GET /upstreams/orders/health": {http.StatusOK, `{"data":[
+			{"target":"10.0.0.1:80","weight":100,"health":"HEALTHY"},
+			{"target":"10.0.0.2:80","weight":20,"health":"UNHEALTHY"}
+		]}`},
+		"POST /upstreams/orders/targets": {http.StatusCreated, `{}`},
+	})
+	defer restore()
+
+	c := newTestContext(t, upstreamShiftCommand.Flags, "--name", "orders", "--from", "10.0.0.1:80", "--to", "10.0.0.2:80", "--steps", "50,100", "--interval", "0s")
+	if err := shiftUpstream(c); err == nil || !strings.Contains(err.Error(), "rolled back") {
+		t.Fatalf("got %v, want a rollback", err)
+	}
+
+	var posts []string
+	for _, r := range *requests {
+		if strings.HasPrefix(r, "POST") {
+			posts = append(posts, strings.TrimPrefix(r, "POST /upstreams/orders/targets "))
+		}
+	}
+
+	want := []string{
+		`{"target":"10.0.0.2:80","weight":50}`,
+		`{"target":"10.0.0.1:80","weight":50}`,
+		//the rollback restores both weights, the to target was active before the shift.
+		`{"target":"10.0.0.1:80","weight":100}`,
+		`{"target":"10.0.0.2:80","weight":20}`,
+	}
+	if !reflect.DeepEqual(posts, want) {
+		t.Errorf("got %v, want %v", posts, want)
+	}
+}
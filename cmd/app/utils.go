@@ -0,0 +1,324 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/xigang/kongctl/common/client"
+)
+
+const (
+	LIST_PAGE_SIZE = "1000"
+)
+
+type pagedList struct {
+	Data   []json.RawMessage `json:"data"`
+	Offset string            `json:"offset,omitempty"`
+}
+
+//notFoundError is returned when kong answers 404, the object or the endpoint does not exist.
+type notFoundError struct {
+	requestURL string
+}
+
+func (e notFoundError) Error() string {
+	return fmt.Sprintf("%s is not found", e.requestURL)
+}
+
+//isNotFound return whether the error is a 404 of kong.
+func isNotFound(err error) bool {
+	_, ok := err.(notFoundError)
+	return ok
+}
+
+//listAllObjects walk every page of a admin api list endpoint and return the raw objects.
+func listAllObjects(requestURL string, q url.Values) ([]json.RawMessage, error) {
+	if q == nil {
+		q = url.Values{}
+	}
+	q.Set("size", LIST_PAGE_SIZE)
+
+	var objects []json.RawMessage
+	for {
+		page, err := listPage(requestURL, q)
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, page.Data...)
+		if page.Offset == "" {
+			break
+		}
+		q.Set("offset", page.Offset)
+	}
+
+	return objects, nil
+}
+
+func listPage(requestURL string, q url.Values) (*pagedList, error) {
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	serverResponse, err := client.GatewayClient.Get(ctx, requestURL, q, nil)
+	if serverResponse.StatusCode == http.StatusNotFound {
+		return nil, notFoundError{requestURL}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(serverResponse.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &pagedList{}
+	if err = json.Unmarshal(body, page); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", requestURL, err)
+	}
+
+	return page, nil
+}
+
+//getObject retrieve a single admin api object and decode it into v.
+func getObject(requestURL string, v interface{}) error {
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	serverResponse, err := client.GatewayClient.Get(ctx, requestURL, nil, nil)
+	if serverResponse.StatusCode == http.StatusNotFound {
+		return notFoundError{requestURL}
+	}
+	if err != nil {
+		return err
+	}
+
+	body, err := ioutil.ReadAll(serverResponse.Body)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+//fetchAllPlugins list every plugin, optionally filtered by name.
+func fetchAllPlugins(name string) ([]CommonPluginConfig, error) {
+	q := url.Values{}
+	if name != "" {
+		q.Add("name", name)
+	}
+
+	objects, err := listAllObjects(PLUGIN_RESOURCE_OBJECT, q)
+	if err != nil {
+		return nil, err
+	}
+
+	plugins := make([]CommonPluginConfig, 0, len(objects))
+	for _, o := range objects {
+		var p CommonPluginConfig
+		if err := json.Unmarshal(o, &p); err != nil {
+			return nil, err
+		}
+		plugins = append(plugins, p)
+	}
+
+	return plugins, nil
+}
+
+//fetchAllConsumers list every consumer.
+func fetchAllConsumers() ([]ConsumerConfig, error) {
+	objects, err := listAllObjects(CONSUMER_RESOURCE_OBJECT, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	consumers := make([]ConsumerConfig, 0, len(objects))
+	for _, o := range objects {
+		var c ConsumerConfig
+		if err := json.Unmarshal(o, &c); err != nil {
+			return nil, err
+		}
+		consumers = append(consumers, c)
+	}
+
+	return consumers, nil
+}
+
+//fetchAllServices list every service.
+func fetchAllServices() ([]ServiceConfig, error) {
+	objects, err := listAllObjects(SERVICE_RESOURCE_OBJECT, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	services := make([]ServiceConfig, 0, len(objects))
+	for _, o := range objects {
+		var s ServiceConfig
+		if err := json.Unmarshal(o, &s); err != nil {
+			return nil, err
+		}
+		services = append(services, s)
+	}
+
+	return services, nil
+}
+
+//fetchAllRoutes list every route.
+func fetchAllRoutes() ([]RouteConfig, error) {
+	objects, err := listAllObjects(ROUTE_RESOURCE_OBJECT, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]RouteConfig, 0, len(objects))
+	for _, o := range objects {
+		var r RouteConfig
+		if err := json.Unmarshal(o, &r); err != nil {
+			return nil, err
+		}
+		routes = append(routes, r)
+	}
+
+	return routes, nil
+}
+
+//gatewayState is the configuration of kong, fetched from the admin api or read from a state file
+//holding {"services": [...], "routes": [...], ...} in the format of the admin api.
+type gatewayState struct {
+	Services  []ServiceConfig      `json:"services"`
+	Routes    []RouteConfig        `json:"routes"`
+	Consumers []ConsumerConfig     `json:"consumers,omitempty"`
+	Plugins   []CommonPluginConfig `json:"plugins,omitempty"`
+	Upstreams []UpstreamConfig     `json:"upstreams,omitempty"`
+	//The active targets of every upstream.
+	Targets      []TargetConfig      `json:"targets,omitempty"`
+	Certificates []CertificateConfig `json:"certificates,omitempty"`
+	SNIs         []SNIConfig         `json:"snis,omitempty"`
+	//PluginsLoaded is false when the plugins are not fetched, or the state file has no plugins.
+	PluginsLoaded bool `json:"-"`
+}
+
+//loadGatewayState read the state file, or fetch the services and routes when the file is empty,
+//and the consumers, plugins, upstreams, targets, certificates and snis too when full is true.
+func loadGatewayState(file string, full bool) (*gatewayState, error) {
+	state := &gatewayState{}
+
+	if file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, state); err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+
+		keys := map[string]json.RawMessage{}
+		if err := json.Unmarshal(data, &keys); err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		_, state.PluginsLoaded = keys["plugins"]
+		return state, nil
+	}
+
+	var err error
+	if state.Services, err = fetchAllServices(); err != nil {
+		return nil, err
+	}
+	if state.Routes, err = fetchAllRoutes(); err != nil {
+		return nil, err
+	}
+
+	if !full {
+		return state, nil
+	}
+
+	if state.Consumers, err = fetchAllConsumers(); err != nil {
+		return nil, err
+	}
+	if state.Plugins, err = fetchAllPlugins(""); err != nil {
+		return nil, err
+	}
+	state.PluginsLoaded = true
+
+	upstreams, err := listAllObjects(UPSTREAM_RESOURCE_OBJECT, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range upstreams {
+		u := UpstreamConfig{}
+		if err := json.Unmarshal(raw, &u); err != nil {
+			return nil, err
+		}
+		state.Upstreams = append(state.Upstreams, u)
+
+		targets, err := listAllObjects(fmt.Sprintf("%s/%s/%s", UPSTREAM_RESOURCE_OBJECT, u.ID, TARGET_RESOURCE_OBJECT), nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, raw := range targets {
+			t := TargetConfig{}
+			if err := json.Unmarshal(raw, &t); err != nil {
+				return nil, err
+			}
+			state.Targets = append(state.Targets, t)
+		}
+	}
+
+	certificates, err := listAllObjects(CERTIFICATE_RESOURCE_OBJECT, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range certificates {
+		cert := CertificateConfig{}
+		if err := json.Unmarshal(raw, &cert); err != nil {
+			return nil, err
+		}
+		state.Certificates = append(state.Certificates, cert)
+	}
+
+	snis, err := listAllObjects(SNI_RESOURCE_OBJECT, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range snis {
+		sni := SNIConfig{}
+		if err := json.Unmarshal(raw, &sni); err != nil {
+			return nil, err
+		}
+		state.SNIs = append(state.SNIs, sni)
+	}
+
+	return state, nil
+}
+
+//service return the service of an id, or nil.
+func (s *gatewayState) service(id string) *ServiceConfig {
+	for i := range s.Services {
+		if s.Services[i].ID == id {
+			return &s.Services[i]
+		}
+	}
+	return nil
+}
+
+//deleteObject delete a admin api object, an object already deleted is not an error.
+func deleteObject(requestURL string) error {
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	serverResponse, err := client.GatewayClient.Delete(ctx, requestURL, nil, nil)
+	if serverResponse.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	serverResponse.Body.Close()
+
+	return nil
+}
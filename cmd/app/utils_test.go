@@ -0,0 +1,93 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/common/client"
+)
+
+//fakeKong serve the admin api paths with a status and a body, other paths answer 404.
+//It returns a function restoring the gateway client.
+func fakeKong(t *testing.T, responses map[string]fakeResponse) func() {
+	_, restore := recordKong(t, responses)
+	return restore
+}
+
+//recordKong is a fakeKong recording the method, path and body of every request.
+func recordKong(t *testing.T, responses map[string]fakeResponse) (*[]string, func()) {
+	requests := &[]string{}
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		*requests = append(*requests, strings.TrimSpace(fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, body)))
+		mu.Unlock()
+
+		resp, ok := responses[r.Method+" "+r.URL.Path]
+		if !ok {
+			resp, ok = responses[r.URL.Path]
+		}
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not found"}`))
+			return
+		}
+		w.WriteHeader(resp.status)
+		w.Write([]byte(resp.body))
+	}))
+
+	old := client.GatewayClient
+	var err error
+	if client.GatewayClient, err = client.NewHTTPClient(server.URL, map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	return requests, func() {
+		client.GatewayClient = old
+		server.Close()
+	}
+}
+
+type fakeResponse struct {
+	status int
+	body   string
+}
+
+func TestIsNotFound(t *testing.T) {
+	defer fakeKong(t, map[string]fakeResponse{
+		"/consumers/c1/key-auth": {http.StatusOK, `{"data":[{"id":"k1"}]}`},
+		"/consumers/c1/jwt":      {http.StatusUnauthorized, `{"message":"Unauthorized"}`},
+	})()
+
+	if _, err := listAllObjects("consumers/c1/key-auth", nil); err != nil {
+		t.Errorf("200: got error %v", err)
+	}
+	if _, err := listAllObjects("consumers/c1/oauth2", nil); !isNotFound(err) {
+		t.Errorf("404: got error %v, want not found", err)
+	}
+	if _, err := listAllObjects("consumers/c1/jwt", nil); err == nil || isNotFound(err) {
+		t.Errorf("401: got error %v, want an error other than not found", err)
+	}
+}
+
+//newTestContext return the context of a command with the flags, parsed from the args.
+func newTestContext(t *testing.T, flags []cli.Flag, args ...string) *cli.Context {
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	for _, f := range flags {
+		f.Apply(set)
+	}
+	if err := set.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return cli.NewContext(cli.NewApp(), set, nil)
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/pkg/plugin/analytics_monitoring"
+)
+
+// The zipkin status lists every service and route with tracing enabled,
+// and the effective sample ratio, including the one inherited from a global plugin.
+
+var ZipkinCommand = cli.Command{
+	Name:  "zipkin",
+	Usage: "The kong zipkin tracing status.",
+
+	Subcommands: []cli.Command{
+		{
+			Name:   "status",
+			Usage:  "list the services and routes with tracing enabled",
+			Action: zipkinStatus,
+		},
+	},
+}
+
+//zipkinStatus print the effective zipkin plugin of every service and route.
+func zipkinStatus(c *cli.Context) error {
+	plugins, err := fetchAllPlugins(analytics_monitoring.PLUGIN_ZIPKIN)
+	if err != nil {
+		return err
+	}
+
+	services, err := fetchAllServices()
+	if err != nil {
+		return err
+	}
+
+	routes, err := fetchAllRoutes()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-8s\t%-40s\t%-20s\t%-45s\t%-12s\t%-40s\n", "TYPE", "ID", "NAME", "INHERITED_FROM", "SAMPLE_RATIO", "HTTP_ENDPOINT")
+
+	for _, s := range services {
+		plugin, scope := resolveRoutePlugin(plugins, analytics_monitoring.PLUGIN_ZIPKIN, "", s.ID)
+		if err := printZipkinStatus("service", s.ID, s.Name, plugin, scope); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range routes {
+		plugin, scope := resolveRoutePlugin(plugins, analytics_monitoring.PLUGIN_ZIPKIN, r.ID, r.Service.ID)
+		if err := printZipkinStatus("route", r.ID, "", plugin, scope); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func printZipkinStatus(kind, id, name string, plugin *CommonPluginConfig, scope string) error {
+	if plugin == nil {
+		return nil
+	}
+
+	cfg := analytics_monitoring.ZipkinConfig{}
+	if err := json.Unmarshal(plugin.Config, &cfg); err != nil {
+		return err
+	}
+
+	fmt.Printf("%-8s\t%-40s\t%-20s\t%-45s\t%-12g\t%-40s\n", kind, id, name, scope, cfg.SampleRatio, cfg.HTTPEndpoint)
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 
 	kongapp "github.com/xigang/kongctl/cmd/app"
 	"github.com/xigang/kongctl/common/client"
+	"github.com/xigang/kongctl/common/config"
 )
 
 func main() {
@@ -29,12 +30,37 @@ func main() {
 			EnvVar: "KONG_AUTH",
 			Usage:  "basic authoritarian for api gateway",
 		},
+		cli.StringFlag{
+			Name:   "context",
+			EnvVar: "KONGCTL_CONTEXT",
+			Usage:  "the kongctl config context, its host and auth are used when --host and --auth are empty",
+		},
 	}
 
 	app.Before = func(c *cli.Context) error {
 		host := c.GlobalString("host")
 		token := c.GlobalString("auth")
 
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+
+		name, kctx, err := cfg.ActiveContext(c.GlobalString("context"))
+		if err != nil {
+			return err
+		}
+		kongapp.SetContext(name, kctx)
+
+		if kctx != nil {
+			if host == "" {
+				host = kctx.Host
+			}
+			if token == "" {
+				token = kctx.Auth
+			}
+		}
+
 		if host == "" {
 			fmt.Printf("please specify the KONG_HOST and KONG_AUTH environment variables")
 		}
@@ -42,7 +68,6 @@ func main() {
 		customHTTPHeaders := make(map[string]string)
 		customHTTPHeaders["Authorization"] = fmt.Sprintf("Basic %s", token)
 
-		var err error
 		if client.GatewayClient, err = client.NewHTTPClient(host, customHTTPHeaders); err != nil {
 			return err
 		}
@@ -59,12 +84,23 @@ func main() {
 		kongapp.SNIResourceObjectCommand,
 		kongapp.UpstreamResourceObjectCommand,
 		kongapp.TargetResourceObjectCommand,
+		kongapp.ACLCommand,
+		kongapp.CORSCommand,
+		kongapp.TransformCommand,
+		kongapp.MetricsCommand,
+		kongapp.ZipkinCommand,
+		kongapp.LintCommand,
+		kongapp.GCCommand,
 	}
 
+	kongapp.ProtectCommands(app.Commands)
+
 	sort.Sort(cli.FlagsByName(app.Flags))
 	sort.Sort(cli.CommandsByName(app.Commands))
 
 	if err := app.Run(os.Args); err != nil {
 		glog.Errorf("%+v", err)
+		glog.Flush()
+		os.Exit(1)
 	}
 }
@@ -74,6 +74,11 @@ func ParseHostURL(host string) (*url.URL, error) {
 	}, nil
 }
 
+//Host return the address of the kong admin api, e.g. http://127.0.0.1:8001.
+func (cli *Client) Host() string {
+	return fmt.Sprintf("%s://%s", cli.scheme, cli.host)
+}
+
 func (cli *Client) Close() error {
 	if t, ok := cli.client.Transport.(*http.Transport); ok {
 		t.CloseIdleConnections()
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xigang/kongctl/common/tools"
+)
+
+//The kongctl config is the json file config.json of the state directory, $KONGCTL_HOME or ~/.kongctl.
+
+const (
+	CONFIG_FILE = "config.json"
+)
+
+type Config struct {
+	//The context used when --context and KONGCTL_CONTEXT are empty.
+	CurrentContext string `json:"current_context,omitempty"`
+	//Named kong nodes, with their own settings.
+	Contexts map[string]*Context `json:"contexts,omitempty"`
+	//Named upstream health checks, in the format of the healthchecks field of a upstream.
+	//They override the built-in profiles of the same name.
+	HealthcheckProfiles map[string]json.RawMessage `json:"healthcheck_profiles,omitempty"`
+	//The lint rules settings of every context.
+	Lint LintConfig `json:"lint,omitempty"`
+}
+
+//Context is a kong node. Its host and auth are used when --host and --auth are empty.
+type Context struct {
+	Host string `json:"host,omitempty"`
+	Auth string `json:"auth,omitempty"`
+	//A protected context requires --yes and to type the context name for every mutating command.
+	Protected bool `json:"protected,omitempty"`
+	//The lint rules settings of the context, they override the ones of the config.
+	Lint LintConfig `json:"lint,omitempty"`
+}
+
+type LintConfig struct {
+	Rules map[string]LintRuleConfig `json:"rules,omitempty"`
+}
+
+type LintRuleConfig struct {
+	//Enable or disable the rule, nil keeps the default.
+	Enabled *bool `json:"enabled,omitempty"`
+	//error, warning or info, empty keeps the default.
+	Severity string `json:"severity,omitempty"`
+	//The options of the rule, e.g. max_timeout, they override the defaults one by one.
+	Options map[string]float64 `json:"options,omitempty"`
+}
+
+//Load read the kongctl config, a missing config file is an empty config.
+func Load() (*Config, error) {
+	cfg := &Config{}
+	if err := tools.ReadState(CONFIG_FILE, cfg); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", Path(), err)
+	}
+	return cfg, nil
+}
+
+//Path return the path of the config file.
+func Path() string {
+	return filepath.Join(tools.StateDir(), CONFIG_FILE)
+}
+
+//ActiveContext return the context of the name, or the current context when the name is empty.
+//No context selected returns an empty name and a nil context.
+func (cfg *Config) ActiveContext(name string) (string, *Context, error) {
+	if name == "" {
+		name = cfg.CurrentContext
+	}
+	if name == "" {
+		return "", nil, nil
+	}
+
+	ctx, ok := cfg.Contexts[name]
+	if !ok {
+		names := make([]string, 0, len(cfg.Contexts))
+		for n := range cfg.Contexts {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return "", nil, fmt.Errorf("context %s is not found in %s, available contexts are %s", name, Path(), strings.Join(names, ", "))
+	}
+	return name, ctx, nil
+}
+
+//LintRules return the lint rules settings of a context merged on the ones of the config.
+func (cfg *Config) LintRules(ctx *Context) map[string]LintRuleConfig {
+	rules := map[string]LintRuleConfig{}
+	for name, rule := range cfg.Lint.Rules {
+		rules[name] = rule
+	}
+
+	if ctx == nil {
+		return rules
+	}
+
+	for name, override := range ctx.Lint.Rules {
+		rule := rules[name]
+		if override.Enabled != nil {
+			rule.Enabled = override.Enabled
+		}
+		if override.Severity != "" {
+			rule.Severity = override.Severity
+		}
+		if len(override.Options) > 0 {
+			options := map[string]float64{}
+			for k, v := range rule.Options {
+				options[k] = v
+			}
+			for k, v := range override.Options {
+				options[k] = v
+			}
+			rule.Options = options
+		}
+		rules[name] = rule
+	}
+
+	return rules
+}
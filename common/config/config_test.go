@@ -0,0 +1,50 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLintRules(t *testing.T) {
+	enabled, disabled := true, false
+
+	cfg := &Config{Lint: LintConfig{Rules: map[string]LintRuleConfig{
+		"service-timeout":      {Severity: "error", Options: map[string]float64{"max_timeout": 30000}},
+		"upstream-few-targets": {Enabled: &disabled, Options: map[string]float64{"min_targets": 3}},
+	}}}
+
+	cases := []struct {
+		name string
+		ctx  *Context
+		want map[string]LintRuleConfig
+	}{
+		{
+			name: "no context",
+			ctx:  nil,
+			want: cfg.Lint.Rules,
+		},
+		{
+			name: "a context overrides the fields it sets",
+			ctx: &Context{Lint: LintConfig{Rules: map[string]LintRuleConfig{
+				"service-timeout":      {Options: map[string]float64{"max_timeout": 90000}},
+				"upstream-few-targets": {Enabled: &enabled},
+				"plugin-disabled":      {Severity: "warning"},
+			}}},
+			want: map[string]LintRuleConfig{
+				"service-timeout":      {Severity: "error", Options: map[string]float64{"max_timeout": 90000}},
+				"upstream-few-targets": {Enabled: &enabled, Options: map[string]float64{"min_targets": 3}},
+				"plugin-disabled":      {Severity: "warning"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		if got := cfg.LintRules(tc.ctx); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+
+	if cfg.Lint.Rules["service-timeout"].Options["max_timeout"] != 30000 {
+		t.Errorf("the context options changed the options of the config")
+	}
+}
@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+//Stdin is where the confirmations are read from.
+var Stdin io.Reader = os.Stdin
+
+//IsTerminal return whether stdin is a terminal, /dev/null is a character device too.
+var IsTerminal = func() bool {
+	fi, err := os.Stdin.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+
+	if null, err := os.Stat(os.DevNull); err == nil && os.SameFile(fi, null) {
+		return false
+	}
+	return true
+}
+
+//Confirm ask a yes/no question on stdin, only y or yes confirms.
+func Confirm(prompt string) (bool, error) {
+	fmt.Printf("%s [y/N]: ", prompt)
+
+	answer, err := bufio.NewReader(Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+//ConfirmName ask to type the name on stdin, anything else aborts.
+func ConfirmName(prompt, name string) error {
+	fmt.Printf("%s\ntype %s to confirm: ", prompt, name)
+
+	answer, err := bufio.NewReader(Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	if strings.TrimSpace(answer) != name {
+		return fmt.Errorf("aborted, %q does not match %s", strings.TrimSpace(answer), name)
+	}
+	return nil
+}
+
+//ConfirmDestructive ask to type the name before a destructive command, unless yes.
+//Without a terminal, required falls back to the yes/no question, otherwise the command runs as it always did.
+func ConfirmDestructive(yes bool, prompt, name string, required bool) error {
+	if yes {
+		return nil
+	}
+
+	if IsTerminal() {
+		return ConfirmName(prompt, name)
+	}
+
+	if !required {
+		return nil
+	}
+
+	ok, err := Confirm(prompt)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("aborted")
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfirmDestructive(t *testing.T) {
+	defer func(terminal func() bool) { IsTerminal = terminal }(IsTerminal)
+
+	cases := []struct {
+		name     string
+		yes      bool
+		terminal bool
+		required bool
+		input    string
+		wantErr  bool
+	}{
+		{name: "yes skips the question", yes: true, terminal: true, input: "", wantErr: false},
+		{name: "terminal with the name", terminal: true, input: "orders\n", wantErr: false},
+		{name: "terminal with another name", terminal: true, input: "order\n", wantErr: true},
+		{name: "terminal with y is not the name", terminal: true, input: "y\n", wantErr: true},
+		{name: "no terminal runs", terminal: false, input: "", wantErr: false},
+		{name: "no terminal required with y", terminal: false, required: true, input: "y\n", wantErr: false},
+		{name: "no terminal required without answer", terminal: false, required: true, input: "", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		terminal := tc.terminal
+		IsTerminal = func() bool { return terminal }
+		Stdin = strings.NewReader(tc.input)
+
+		err := ConfirmDestructive(tc.yes, "delete service orders.", "orders", tc.required)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
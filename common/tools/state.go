@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	//How long to wait for the lock of a state file.
+	STATE_LOCK_TIMEOUT = 30 * time.Second
+	//A lock older than this is left by a killed kongctl, and is broken.
+	STATE_LOCK_STALE = 10 * time.Minute
+)
+
+//StateDir return the directory of the kongctl local state, $KONGCTL_HOME or ~/.kongctl.
+func StateDir() string {
+	if dir := os.Getenv("KONGCTL_HOME"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.Getenv("HOME"), ".kongctl")
+}
+
+//ReadState decode the json state file name of the state directory into v, a missing file leaves v untouched.
+func ReadState(name string, v interface{}) error {
+	data, err := ioutil.ReadFile(filepath.Join(StateDir(), name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+//WriteState encode v into the json state file name of the state directory, the file is only readable by the owner.
+func WriteState(name string, v interface{}) error {
+	dir := StateDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	//write then rename, so an interrupted write never truncates the state.
+	tmp := filepath.Join(dir, name+".tmp")
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, name))
+}
+
+//LockState take the lock of the state file name, so concurrent kongctl do not lose each other's updates.
+//The returned function releases the lock.
+func LockState(name string) (func(), error) {
+	dir := StateDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	lock := filepath.Join(dir, name+".lock")
+	deadline := time.Now().Add(STATE_LOCK_TIMEOUT)
+	for {
+		f, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() { os.Remove(lock) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if fi, err := os.Stat(lock); err == nil && time.Since(fi.ModTime()) > STATE_LOCK_STALE {
+			os.Remove(lock)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("state %s is locked by another kongctl, remove %s if none is running", name, lock)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+//UpdateState read the state file name into v, call update, then write v back, under the lock of the state file.
+func UpdateState(name string, v interface{}, update func() error) error {
+	unlock, err := LockState(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := ReadState(name, v); err != nil {
+		return err
+	}
+	if err := update(); err != nil {
+		return err
+	}
+	return WriteState(name, v)
+}
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestUpdateState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kongctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("KONGCTL_HOME", os.Getenv("KONGCTL_HOME"))
+	os.Setenv("KONGCTL_HOME", dir)
+
+	for i := 0; i < 3; i++ {
+		var counts []int
+		err := UpdateState("counts.json", &counts, func() error {
+			counts = append(counts, i)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var counts []int
+	if err := ReadState("counts.json", &counts); err != nil {
+		t.Fatal(err)
+	}
+	if len(counts) != 3 {
+		t.Errorf("got %v, want 3 updates", counts)
+	}
+
+	unlock, err := LockState("counts.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	if _, err := os.Stat(dir + "/counts.json.lock"); err != nil {
+		t.Errorf("lock file: %v", err)
+	}
+}
@@ -1 +1,332 @@
 package analytics_monitoring
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/common/client"
+	"github.com/xigang/kongctl/common/tools"
+	"github.com/xigang/kongctl/pkg/plugin/utils"
+)
+
+//Prometheus
+//https://docs.konghq.com/hub/kong-inc/prometheus/
+
+//Expose metrics related to Kong and proxied upstream services in Prometheus exposition format,
+//which can be scraped by a Prometheus Server. The metrics are available on the admin api at /metrics.
+
+const (
+	PLUGIN_PROMETHEUS = "prometheus"
+)
+
+type Prometheus struct {
+	//The name of the plugin to use, in this case prometheus
+	Name string `json:"name"`
+}
+
+var PrometheusCommand = cli.Command{
+	Name:  "prometheus",
+	Usage: "expose metrics in prometheus exposition format, globally or per service",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "service_id",
+			Usage: "the unique identifier of the Service that should be associated to the newly-created plugin, global if empty",
+		},
+	},
+	Action: createPrometheusPlugin,
+}
+
+//createPrometheusPlugin create a prometheus plugin for kong api gateway.
+func createPrometheusPlugin(c *cli.Context) error {
+	requestURL := utils.PluginRequestURL(c.String("service_id"), "")
+
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	serverResponse, err := client.GatewayClient.Post(ctx, requestURL, nil, Prometheus{Name: PLUGIN_PROMETHEUS}, nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := ioutil.ReadAll(serverResponse.Body)
+	if err != nil {
+		return err
+	}
+
+	tools.IndentFromBody(body)
+	return nil
+}
+
+//Sample is a single sample of the prometheus text exposition format.
+type Sample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+//ParseMetrics parse the prometheus text exposition format, comments and blank lines are skipped.
+func ParseMetrics(r io.Reader) ([]Sample, error) {
+	var samples []Sample
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		s, err := parseSample(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		samples = append(samples, s)
+	}
+
+	return samples, scanner.Err()
+}
+
+//parseSample parse a line like name{label="value",...} value [timestamp].
+func parseSample(text string) (Sample, error) {
+	s := Sample{Labels: map[string]string{}}
+
+	i := strings.IndexAny(text, "{ \t")
+	if i < 0 {
+		return s, fmt.Errorf("sample %q has no value", text)
+	}
+	s.Name = text[:i]
+	rest := text[i:]
+
+	if rest[0] == '{' {
+		end, err := parseLabels(rest, s.Labels)
+		if err != nil {
+			return s, err
+		}
+		rest = rest[end:]
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return s, fmt.Errorf("sample %q has no value", text)
+	}
+
+	value, err := parseValue(fields[0])
+	if err != nil {
+		return s, err
+	}
+	s.Value = value
+
+	return s, nil
+}
+
+//parseLabels parse the label set starting at text[0] == '{' and return the index after the closing brace.
+func parseLabels(text string, labels map[string]string) (int, error) {
+	i := 1
+	for {
+		for i < len(text) && (text[i] == ' ' || text[i] == ',') {
+			i++
+		}
+		if i >= len(text) {
+			return 0, fmt.Errorf("unterminated label set in %q", text)
+		}
+		if text[i] == '}' {
+			return i + 1, nil
+		}
+
+		eq := strings.IndexByte(text[i:], '=')
+		if eq < 0 || i+eq+1 >= len(text) || text[i+eq+1] != '"' {
+			return 0, fmt.Errorf("invalid label in %q", text)
+		}
+		name := strings.TrimSpace(text[i : i+eq])
+		i += eq + 2
+
+		var value strings.Builder
+		for ; i < len(text) && text[i] != '"'; i++ {
+			if text[i] == '\\' && i+1 < len(text) {
+				i++
+				switch text[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(text[i])
+				}
+				continue
+			}
+			value.WriteByte(text[i])
+		}
+		if i >= len(text) {
+			return 0, fmt.Errorf("unterminated label value in %q", text)
+		}
+		i++
+
+		labels[name] = value.String()
+	}
+}
+
+func parseValue(v string) (float64, error) {
+	switch v {
+	case "+Inf":
+		return math.Inf(1), nil
+	case "-Inf":
+		return math.Inf(-1), nil
+	case "NaN":
+		return math.NaN(), nil
+	}
+	return strconv.ParseFloat(v, 64)
+}
+
+//ServiceMetrics is the traffic of a service summarized from the kong metrics.
+type ServiceMetrics struct {
+	Service  string
+	Requests float64
+	//Requests per status class, 2xx, 3xx, 4xx and 5xx.
+	Status map[string]float64
+	//Request latency percentiles in milliseconds.
+	P50, P95, P99 float64
+}
+
+//TargetHealth is the health of a upstream target reported by kong.
+type TargetHealth struct {
+	Upstream string
+	Target   string
+	State    string
+}
+
+//serviceLabel return the service of a sample, kong labels it api before 0.14.
+func serviceLabel(labels map[string]string) string {
+	if s := labels["service"]; s != "" {
+		return s
+	}
+	if s := labels["api"]; s != "" {
+		return s
+	}
+	return "-"
+}
+
+type bucket struct {
+	le    float64
+	count float64
+}
+
+//SummarizeServices aggregate the request counts and latency percentiles per service.
+func SummarizeServices(samples []Sample) []ServiceMetrics {
+	services := map[string]*ServiceMetrics{}
+	buckets := map[string][]bucket{}
+
+	get := func(name string) *ServiceMetrics {
+		m, ok := services[name]
+		if !ok {
+			m = &ServiceMetrics{Service: name, Status: map[string]float64{}}
+			services[name] = m
+		}
+		return m
+	}
+
+	for _, s := range samples {
+		switch s.Name {
+		case "kong_http_status":
+			m := get(serviceLabel(s.Labels))
+			m.Requests += s.Value
+			if code := s.Labels["code"]; code != "" {
+				m.Status[code[:1]+"xx"] += s.Value
+			}
+		case "kong_latency_bucket":
+			if s.Labels["type"] != "request" {
+				continue
+			}
+			le, err := parseValue(s.Labels["le"])
+			if err != nil {
+				continue
+			}
+			name := serviceLabel(s.Labels)
+			get(name)
+			buckets[name] = append(buckets[name], bucket{le, s.Value})
+		}
+	}
+
+	result := make([]ServiceMetrics, 0, len(services))
+	for name, m := range services {
+		m.P50 = histogramQuantile(0.50, buckets[name])
+		m.P95 = histogramQuantile(0.95, buckets[name])
+		m.P99 = histogramQuantile(0.99, buckets[name])
+		result = append(result, *m)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Service < result[j].Service })
+	return result
+}
+
+//histogramQuantile estimate a quantile from cumulative histogram buckets, like the promql function of the same name.
+func histogramQuantile(q float64, buckets []bucket) float64 {
+	if len(buckets) == 0 {
+		return math.NaN()
+	}
+
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].le < buckets[j].le })
+
+	total := buckets[len(buckets)-1].count
+	if total == 0 {
+		return math.NaN()
+	}
+
+	rank := q * total
+	for i, b := range buckets {
+		if b.count < rank {
+			continue
+		}
+
+		if math.IsInf(b.le, 1) {
+			//the quantile falls in the +Inf bucket, the best estimate is the highest finite bound.
+			if i == 0 {
+				return math.NaN()
+			}
+			return buckets[i-1].le
+		}
+
+		lower, lowerCount := 0.0, 0.0
+		if i > 0 {
+			lower, lowerCount = buckets[i-1].le, buckets[i-1].count
+		}
+		if b.count == lowerCount {
+			return b.le
+		}
+		return lower + (b.le-lower)*(rank-lowerCount)/(b.count-lowerCount)
+	}
+
+	return buckets[len(buckets)-1].le
+}
+
+//SummarizeTargetHealth return the health of every upstream target, only reported by recent prometheus plugin versions.
+func SummarizeTargetHealth(samples []Sample) []TargetHealth {
+	var result []TargetHealth
+
+	for _, s := range samples {
+		if s.Name != "kong_upstream_target_health" || s.Value != 1 {
+			continue
+		}
+		result = append(result, TargetHealth{
+			Upstream: s.Labels["upstream"],
+			Target:   s.Labels["target"],
+			State:    s.Labels["state"],
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Upstream != result[j].Upstream {
+			return result[i].Upstream < result[j].Upstream
+		}
+		return result[i].Target < result[j].Target
+	})
+	return result
+}
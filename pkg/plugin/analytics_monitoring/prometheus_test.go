@@ -0,0 +1,126 @@
+package analytics_monitoring
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMetrics(t *testing.T) {
+	cases := []struct {
+		input   string
+		want    []Sample
+		wantErr bool
+	}{
+		{
+			input: "# HELP kong_nginx_metric_errors_total Number of nginx-lua-prometheus errors\n" +
+				"# TYPE kong_nginx_metric_errors_total counter\n" +
+				"\n" +
+				"kong_nginx_metric_errors_total 0\n",
+			want: []Sample{{Name: "kong_nginx_metric_errors_total", Labels: map[string]string{}, Value: 0}},
+		},
+		{
+			input: `kong_http_status{code="200",service="orders"} 42 1600000000000`,
+			want:  []Sample{{Name: "kong_http_status", Labels: map[string]string{"code": "200", "service": "orders"}, Value: 42}},
+		},
+		{
+			input: `kong_latency_bucket{type="request", le="+Inf",} 7`,
+			want:  []Sample{{Name: "kong_latency_bucket", Labels: map[string]string{"type": "request", "le": "+Inf"}, Value: 7}},
+		},
+		{
+			input: `kong_metric{path="/a\"b\\c\n"} 1.5e3`,
+			want:  []Sample{{Name: "kong_metric", Labels: map[string]string{"path": "/a\"b\\c\n"}, Value: 1500}},
+		},
+		{input: `kong_http_status`, wantErr: true},
+		{input: `kong_http_status{code="200"}`, wantErr: true},
+		{input: `kong_http_status{code="200" 1`, wantErr: true},
+		{input: `kong_http_status{code=200} 1`, wantErr: true},
+		{input: `kong_http_status one`, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		got, err := ParseMetrics(strings.NewReader(tc.input))
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%q: got error %v, want error %v", tc.input, err, tc.wantErr)
+			continue
+		}
+		if !tc.wantErr && !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: got %+v, want %+v", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	buckets := []bucket{{100, 90}, {10, 50}, {math.Inf(1), 100}, {1000, 100}}
+
+	cases := []struct {
+		q, want float64
+	}{
+		{0.50, 10},
+		{0.70, 55},
+		{0.95, 550},
+		{0.99, 910},
+	}
+
+	for _, tc := range cases {
+		if got := histogramQuantile(tc.q, buckets); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("q%g: got %g, want %g", tc.q, got, tc.want)
+		}
+	}
+
+	//the quantile falls in the +Inf bucket.
+	if got := histogramQuantile(0.99, []bucket{{10, 50}, {math.Inf(1), 100}}); got != 10 {
+		t.Errorf("+Inf bucket: got %g, want the highest finite bound 10", got)
+	}
+	if got := histogramQuantile(0.5, nil); !math.IsNaN(got) {
+		t.Errorf("no bucket: got %g, want NaN", got)
+	}
+	if got := histogramQuantile(0.5, []bucket{{10, 0}, {math.Inf(1), 0}}); !math.IsNaN(got) {
+		t.Errorf("no request: got %g, want NaN", got)
+	}
+}
+
+func TestSummarizeMetrics(t *testing.T) {
+	input := `
+kong_http_status{code="200",service="orders"} 90
+kong_http_status{code="503",service="orders"} 10
+kong_http_status{code="404",api="legacy"} 5
+kong_latency_bucket{type="request",service="orders",le="10"} 50
+kong_latency_bucket{type="request",service="orders",le="100"} 90
+kong_latency_bucket{type="request",service="orders",le="1000"} 100
+kong_latency_bucket{type="request",service="orders",le="+Inf"} 100
+kong_latency_bucket{type="upstream",service="orders",le="10"} 100
+kong_upstream_target_health{upstream="orders.upstream",target="10.0.0.2:80",state="healthy"} 1
+kong_upstream_target_health{upstream="orders.upstream",target="10.0.0.1:80",state="unhealthy"} 1
+kong_upstream_target_health{upstream="orders.upstream",target="10.0.0.1:80",state="healthy"} 0
+`
+	samples, err := ParseMetrics(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	services := SummarizeServices(samples)
+	if len(services) != 2 || services[0].Service != "legacy" || services[1].Service != "orders" {
+		t.Fatalf("got services %+v", services)
+	}
+
+	orders := services[1]
+	if orders.Requests != 100 || orders.Status["2xx"] != 90 || orders.Status["5xx"] != 10 {
+		t.Errorf("orders: got %v requests, status %v", orders.Requests, orders.Status)
+	}
+	if orders.P50 != 10 || orders.P95 != 550 || orders.P99 != 910 {
+		t.Errorf("orders: got p50 %g, p95 %g, p99 %g", orders.P50, orders.P95, orders.P99)
+	}
+	if legacy := services[0]; legacy.Requests != 5 || !math.IsNaN(legacy.P50) {
+		t.Errorf("legacy: got %+v", legacy)
+	}
+
+	want := []TargetHealth{
+		{Upstream: "orders.upstream", Target: "10.0.0.1:80", State: "unhealthy"},
+		{Upstream: "orders.upstream", Target: "10.0.0.2:80", State: "healthy"},
+	}
+	if got := SummarizeTargetHealth(samples); !reflect.DeepEqual(got, want) {
+		t.Errorf("target health: got %+v, want %+v", got, want)
+	}
+}
@@ -1 +1,132 @@
 package analytics_monitoring
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/common/client"
+	"github.com/xigang/kongctl/common/tools"
+	"github.com/xigang/kongctl/pkg/plugin/utils"
+)
+
+//Zipkin
+//https://docs.konghq.com/hub/kong-inc/zipkin/
+
+//Propagate Zipkin distributed tracing spans, and report spans to a Zipkin server.
+
+const (
+	PLUGIN_ZIPKIN = "zipkin"
+)
+
+//the header types kong uses when no tracing header is found in the request.
+var zipkinHeaderTypes = []string{"preserve", "b3", "b3-single", "w3c", "jaeger", "ot"}
+
+type Zipkin struct {
+	//The name of the plugin to use, in this case zipkin
+	Name string `json:"name"`
+	//Consumer_id is the id of the Consumer we want to associate with this plugin.
+	ConsumerID string       `json:"consumer_id,omitempty"`
+	Enabled    *bool        `json:"enabled,omitempty"`
+	Config     ZipkinConfig `json:"config"`
+}
+
+type ZipkinConfig struct {
+	//The full HTTP(S) endpoint to which Zipkin spans should be sent by Kong.
+	HTTPEndpoint string `json:"http_endpoint"`
+	//How often to sample requests that do not contain trace ids. Set to 0 to turn sampling off, or to 1 to sample all requests.
+	SampleRatio float64 `json:"sample_ratio"`
+	//Should the credential of the currently authenticated consumer be included in metadata sent to the Zipkin server?
+	IncludeCredential bool `json:"include_credential"`
+	//Allows specifying the type of header to be added to requests with no pre-existing tracing headers.
+	DefaultHeaderType string `json:"default_header_type,omitempty"`
+}
+
+var ZipkinCommand = cli.Command{
+	Name:  "zipkin",
+	Usage: "propagate zipkin distributed tracing spans, and report spans to a zipkin server",
+	Flags: append(utils.CommonPluginFlags, []cli.Flag{
+		cli.StringFlag{Name: "http_endpoint", Usage: "The full HTTP(S) endpoint to which Zipkin spans should be sent by Kong"},
+		cli.Float64Flag{Name: "sample_ratio", Value: 0.001, Usage: "How often to sample requests that do not contain trace ids, in [0,1]"},
+		cli.BoolTFlag{Name: "include_credential", Usage: "Include the credential of the currently authenticated consumer in metadata sent to the Zipkin server"},
+		cli.StringFlag{Name: "default_header_type", Usage: "The type of header to be added to requests with no pre-existing tracing headers: preserve, b3, b3-single, w3c, jaeger, ot"},
+	}...),
+	Action: createZipkinPlugin,
+}
+
+//createZipkinPlugin create a zipkin plugin for kong api gateway.
+func createZipkinPlugin(c *cli.Context) error {
+	config := ZipkinConfig{
+		HTTPEndpoint:      c.String("http_endpoint"),
+		SampleRatio:       c.Float64("sample_ratio"),
+		IncludeCredential: c.BoolT("include_credential"),
+		DefaultHeaderType: c.String("default_header_type"),
+	}
+
+	if err := ValidateZipkinConfig(config); err != nil {
+		return err
+	}
+
+	requestURL := utils.PluginRequestURL(c.String("service_id"), c.String("route_id"))
+
+	zipkin := Zipkin{
+		Name:       PLUGIN_ZIPKIN,
+		ConsumerID: c.String("consumer_id"),
+		Enabled:    utils.PluginEnabled(c),
+		Config:     config,
+	}
+
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	serverResponse, err := client.GatewayClient.Post(ctx, requestURL, nil, zipkin, nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := ioutil.ReadAll(serverResponse.Body)
+	if err != nil {
+		return err
+	}
+
+	tools.IndentFromBody(body)
+	return nil
+}
+
+//ValidateZipkinConfig check the http endpoint is a absolute http(s) url and the sample ratio is in [0,1].
+func ValidateZipkinConfig(config ZipkinConfig) error {
+	if config.HTTPEndpoint == "" {
+		return fmt.Errorf("http_endpoint is not allow empty")
+	}
+
+	u, err := url.Parse(config.HTTPEndpoint)
+	if err != nil {
+		return fmt.Errorf("http_endpoint %s is invalid: %v", config.HTTPEndpoint, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("http_endpoint %s is invalid, expected a http(s) url such as http://zipkin:9411/api/v2/spans", config.HTTPEndpoint)
+	}
+
+	if config.SampleRatio < 0 || config.SampleRatio > 1 {
+		return fmt.Errorf("sample_ratio %v is invalid, it must be in [0,1]", config.SampleRatio)
+	}
+
+	if config.DefaultHeaderType != "" {
+		valid := false
+		for _, t := range zipkinHeaderTypes {
+			if t == config.DefaultHeaderType {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("default_header_type %s is invalid, available values are %v", config.DefaultHeaderType, zipkinHeaderTypes)
+		}
+	}
+
+	return nil
+}
@@ -113,26 +113,30 @@ func createBasicAuthCredential(c *cli.Context) error {
 		return fmt.Errorf("consumer: %s username: %s password: %s is not allow empty", consumerID, username, password)
 	}
 
-	cfg := &BasicAuthCredential{
+	body, err := CreateBasicAuthCredential(consumerID, &BasicAuthCredential{
 		Username: username,
 		Password: password,
+	})
+	if err != nil {
+		return err
 	}
 
+	tools.IndentFromBody(body)
+	return nil
+}
+
+//CreateBasicAuthCredential create a basic auth credential for consumer and return the created credential.
+func CreateBasicAuthCredential(consumerID string, cfg *BasicAuthCredential) ([]byte, error) {
 	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cannel()
 
-	requestURL := fmt.Sprintf("consumers/%s/basic-auth", consumerID)
+	requestURL := fmt.Sprintf("consumers/%s/%s", consumerID, PLUGIN_BASIC_AUTH)
 
 	serverResponse, err := client.GatewayClient.Post(ctx, requestURL, nil, cfg, nil)
 	if err != nil {
-		return err
-	}
-
-	body, err := ioutil.ReadAll(serverResponse.Body)
-	if err != nil {
-		return err
+		return nil, err
 	}
+	defer serverResponse.Body.Close()
 
-	tools.IndentFromBody(body)
-	return nil
+	return ioutil.ReadAll(serverResponse.Body)
 }
@@ -0,0 +1,73 @@
+package authentication
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/xigang/kongctl/common/client"
+)
+
+//Key Authentication
+//https://docs.konghq.com/hub/kong-inc/key-auth/
+
+//Add Key Authentication (also referred to as an API key) to a Service or a Route.
+//Consumers then add their key either in a querystring parameter or a header to authenticate their requests.
+
+const (
+	PLUGIN_KEY_AUTH = "key-auth"
+)
+
+type KeyAuthCredential struct {
+	//You can optionally set your own unique key to authenticate the client. If missing, the plugin will generate one.
+	Key string `json:"key,omitempty"`
+}
+
+//CreateKeyAuthCredential create a key auth credential for consumer and return the created credential.
+func CreateKeyAuthCredential(consumerID string, cfg *KeyAuthCredential) ([]byte, error) {
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	requestURL := fmt.Sprintf("consumers/%s/%s", consumerID, PLUGIN_KEY_AUTH)
+
+	serverResponse, err := client.GatewayClient.Post(ctx, requestURL, nil, cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer serverResponse.Body.Close()
+
+	return ioutil.ReadAll(serverResponse.Body)
+}
+
+//DeleteCredential delete a credential of an authentication plugin from consumer.
+//A credential kong does not know is not an error, found is false then.
+func DeleteCredential(consumerID, plugin, id string) (found bool, err error) {
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	requestURL := fmt.Sprintf("consumers/%s/%s/%s", consumerID, plugin, id)
+
+	serverResponse, err := client.GatewayClient.Delete(ctx, requestURL, nil, nil)
+	if serverResponse.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	serverResponse.Body.Close()
+
+	return true, nil
+}
+
+//GenerateSecret return a random hex secret of n bytes.
+func GenerateSecret(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -1 +1,213 @@
 package deployment
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/common/client"
+	"github.com/xigang/kongctl/common/tools"
+	"github.com/xigang/kongctl/pkg/plugin/utils"
+)
+
+//AWS Lambda
+//https://docs.konghq.com/hub/kong-inc/aws-lambda/
+
+//Invoke an AWS Lambda function from Kong. It can be used in combination with other request plugins to secure, manage or extend the function.
+
+const (
+	PLUGIN_AWS_LAMBDA = "aws-lambda"
+)
+
+//the regions supported by the kong aws-lambda plugin.
+var awsRegions = []string{
+	"us-east-1", "us-east-2", "us-west-1", "us-west-2",
+	"ap-northeast-1", "ap-northeast-2", "ap-south-1", "ap-southeast-1", "ap-southeast-2",
+	"ca-central-1", "cn-north-1", "cn-northwest-1",
+	"eu-central-1", "eu-west-1", "eu-west-2", "eu-west-3",
+	"sa-east-1", "us-gov-west-1",
+}
+
+type AWSLambda struct {
+	//The name of the plugin to use, in this case aws-lambda
+	Name string `json:"name"`
+	//Consumer_id is the id of the Consumer we want to associate with this plugin.
+	ConsumerID string          `json:"consumer_id,omitempty"`
+	Enabled    *bool           `json:"enabled,omitempty"`
+	Config     AWSLambdaConfig `json:"config"`
+}
+
+type AWSLambdaConfig struct {
+	//The AWS key credential to be used when invoking the function
+	AWSKey string `json:"aws_key"`
+	//The AWS secret credential to be used when invoking the function
+	AWSSecret string `json:"aws_secret"`
+	//The AWS region where the Lambda function is located.
+	AWSRegion string `json:"aws_region"`
+	//The AWS Lambda function name to invoke
+	FunctionName string `json:"function_name"`
+	//The Qualifier to use when invoking the function.
+	Qualifier string `json:"qualifier,omitempty"`
+	//The InvocationType to use when invoking the function. Available types are RequestResponse, Event, DryRun
+	InvocationType string `json:"invocation_type,omitempty"`
+	//The LogType to use when invoking the function. By default None and Tail are supported
+	LogType string `json:"log_type,omitempty"`
+	//An optional timeout in milliseconds when invoking the function
+	Timeout int `json:"timeout,omitempty"`
+	//An optional value in milliseconds that defines how long an idle connection will live before being closed
+	Keepalive int `json:"keepalive,omitempty"`
+	//An optional value that defines whether the request method verb, is sent in the request_method field of the JSON-encoded request
+	ForwardRequestMethod bool `json:"forward_request_method"`
+	//An optional value that defines whether the original HTTP request URI is sent in the request_uri field of the JSON-encoded request
+	ForwardRequestURI bool `json:"forward_request_uri"`
+	//An optional value that defines whether the original HTTP request headers are sent as a map in the request_headers field of the JSON-encoded request
+	ForwardRequestHeaders bool `json:"forward_request_headers"`
+	//An optional value that defines whether the original HTTP request body is sent in the request_body field of the JSON-encoded request
+	ForwardRequestBody bool `json:"forward_request_body"`
+	//An optional value that defines whether the response format to receive from the Lambda to this format
+	IsProxyIntegration bool `json:"is_proxy_integration"`
+}
+
+var AWSLambdaCommand = cli.Command{
+	Name:  "aws-lambda",
+	Usage: "invoke an aws lambda function from kong",
+	Flags: append(utils.CommonPluginFlags, []cli.Flag{
+		cli.StringFlag{Name: "aws_key", EnvVar: "AWS_ACCESS_KEY_ID", Usage: "The AWS key credential to be used when invoking the function"},
+		cli.StringFlag{Name: "aws_key_file", Usage: "A file holding the AWS key credential, instead of aws_key"},
+		cli.StringFlag{Name: "aws_secret", EnvVar: "AWS_SECRET_ACCESS_KEY", Usage: "The AWS secret credential to be used when invoking the function"},
+		cli.StringFlag{Name: "aws_secret_file", Usage: "A file holding the AWS secret credential, instead of aws_secret"},
+		cli.StringFlag{Name: "aws_region", EnvVar: "AWS_REGION", Usage: "The AWS region where the Lambda function is located"},
+		cli.StringFlag{Name: "function_name", Usage: "The AWS Lambda function name to invoke"},
+		cli.StringFlag{Name: "qualifier", Usage: "The Qualifier to use when invoking the function"},
+		cli.StringFlag{Name: "invocation_type", Value: "RequestResponse", Usage: "The InvocationType to use when invoking the function: RequestResponse, Event, DryRun"},
+		cli.StringFlag{Name: "log_type", Value: "Tail", Usage: "The LogType to use when invoking the function: None, Tail"},
+		cli.IntFlag{Name: "timeout", Value: 60000, Usage: "An optional timeout in milliseconds when invoking the function"},
+		cli.IntFlag{Name: "keepalive", Value: 60000, Usage: "An optional value in milliseconds that defines how long an idle connection will live before being closed"},
+		cli.BoolFlag{Name: "forward_request_method", Usage: "Send the request method verb in the request_method field of the JSON-encoded request"},
+		cli.BoolFlag{Name: "forward_request_uri", Usage: "Send the original HTTP request URI in the request_uri field of the JSON-encoded request"},
+		cli.BoolFlag{Name: "forward_request_headers", Usage: "Send the original HTTP request headers in the request_headers field of the JSON-encoded request"},
+		cli.BoolFlag{Name: "forward_request_body", Usage: "Send the original HTTP request body in the request_body field of the JSON-encoded request"},
+		cli.BoolFlag{Name: "is_proxy_integration", Usage: "Transform the Lambda response from the API Gateway proxy integration format"},
+	}...),
+	Action: createAWSLambdaPlugin,
+}
+
+//createAWSLambdaPlugin create a aws-lambda plugin for kong api gateway.
+func createAWSLambdaPlugin(c *cli.Context) error {
+	awsKey, err := readSecret(c, "aws_key", "AWS_ACCESS_KEY_ID")
+	if err != nil {
+		return err
+	}
+
+	awsSecret, err := readSecret(c, "aws_secret", "AWS_SECRET_ACCESS_KEY")
+	if err != nil {
+		return err
+	}
+
+	config := AWSLambdaConfig{
+		AWSKey:                awsKey,
+		AWSSecret:             awsSecret,
+		AWSRegion:             c.String("aws_region"),
+		FunctionName:          c.String("function_name"),
+		Qualifier:             c.String("qualifier"),
+		InvocationType:        c.String("invocation_type"),
+		LogType:               c.String("log_type"),
+		Timeout:               c.Int("timeout"),
+		Keepalive:             c.Int("keepalive"),
+		ForwardRequestMethod:  c.Bool("forward_request_method"),
+		ForwardRequestURI:     c.Bool("forward_request_uri"),
+		ForwardRequestHeaders: c.Bool("forward_request_headers"),
+		ForwardRequestBody:    c.Bool("forward_request_body"),
+		IsProxyIntegration:    c.Bool("is_proxy_integration"),
+	}
+
+	if err := ValidateAWSLambdaConfig(config); err != nil {
+		return err
+	}
+
+	requestURL := utils.PluginRequestURL(c.String("service_id"), c.String("route_id"))
+
+	lambda := AWSLambda{
+		Name:       PLUGIN_AWS_LAMBDA,
+		ConsumerID: c.String("consumer_id"),
+		Enabled:    utils.PluginEnabled(c),
+		Config:     config,
+	}
+
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	serverResponse, err := client.GatewayClient.Post(ctx, requestURL, nil, lambda, nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := ioutil.ReadAll(serverResponse.Body)
+	if err != nil {
+		return err
+	}
+
+	tools.IndentFromBody(body)
+	return nil
+}
+
+//readSecret read a credential from <name>_file, or from the <name> flag which also falls back to its environment variable,
+//so secrets never have to appear in the shell history.
+func readSecret(c *cli.Context, name, envVar string) (string, error) {
+	if file := c.String(name + "_file"); file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s from %s: %v", name, file, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	if c.IsSet(name) && os.Getenv(envVar) == "" {
+		fmt.Fprintf(os.Stderr, "warning: passing %s on the command line leaves it in the shell history, prefer %s_file or %s\n", name, name, envVar)
+	}
+
+	return c.String(name), nil
+}
+
+//ValidateAWSLambdaConfig check the required fields and the enumerations of a aws-lambda config.
+func ValidateAWSLambdaConfig(config AWSLambdaConfig) error {
+	if config.AWSKey == "" || config.AWSSecret == "" {
+		return fmt.Errorf("aws_key and aws_secret are required, set them with aws_key_file/aws_secret_file or AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY")
+	}
+
+	if config.FunctionName == "" {
+		return fmt.Errorf("function_name is not allow empty")
+	}
+
+	if !contains(awsRegions, config.AWSRegion) {
+		return fmt.Errorf("aws_region %q is invalid, available regions are %s", config.AWSRegion, strings.Join(awsRegions, ", "))
+	}
+
+	if !contains([]string{"RequestResponse", "Event", "DryRun"}, config.InvocationType) {
+		return fmt.Errorf("invocation_type %s is invalid, available values are RequestResponse, Event, DryRun", config.InvocationType)
+	}
+
+	if !contains([]string{"None", "Tail"}, config.LogType) {
+		return fmt.Errorf("log_type %s is invalid, available values are None, Tail", config.LogType)
+	}
+
+	if config.Timeout <= 0 || config.Keepalive <= 0 {
+		return fmt.Errorf("timeout: %d keepalive: %d must be positive", config.Timeout, config.Keepalive)
+	}
+
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, l := range list {
+		if l == value {
+			return true
+		}
+	}
+	return false
+}
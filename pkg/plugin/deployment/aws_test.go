@@ -0,0 +1,101 @@
+package deployment
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestValidateAWSLambdaConfig(t *testing.T) {
+	valid := AWSLambdaConfig{
+		AWSKey:         "AKIA",
+		AWSSecret:      "secret",
+		AWSRegion:      "us-east-1",
+		FunctionName:   "orders",
+		InvocationType: "RequestResponse",
+		LogType:        "Tail",
+		Timeout:        60000,
+		Keepalive:      60000,
+	}
+
+	cases := []struct {
+		name    string
+		edit    func(c *AWSLambdaConfig)
+		wantErr bool
+	}{
+		{name: "valid", edit: func(c *AWSLambdaConfig) {}},
+		{name: "no secret", edit: func(c *AWSLambdaConfig) { c.AWSSecret = "" }, wantErr: true},
+		{name: "no function name", edit: func(c *AWSLambdaConfig) { c.FunctionName = "" }, wantErr: true},
+		{name: "unknown region", edit: func(c *AWSLambdaConfig) { c.AWSRegion = "us-east-9" }, wantErr: true},
+		{name: "empty region", edit: func(c *AWSLambdaConfig) { c.AWSRegion = "" }, wantErr: true},
+		{name: "invalid invocation_type", edit: func(c *AWSLambdaConfig) { c.InvocationType = "requestresponse" }, wantErr: true},
+		{name: "invalid log_type", edit: func(c *AWSLambdaConfig) { c.LogType = "Full" }, wantErr: true},
+		{name: "negative timeout", edit: func(c *AWSLambdaConfig) { c.Timeout = -1 }, wantErr: true},
+	}
+
+	for _, tc := range cases {
+		config := valid
+		tc.edit(&config)
+		if err := ValidateAWSLambdaConfig(config); (err != nil) != tc.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestReadSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kongctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "secret")
+	if err := ioutil.WriteFile(file, []byte("  from-file\n\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Setenv("AWS_SECRET_ACCESS_KEY", os.Getenv("AWS_SECRET_ACCESS_KEY"))
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "from-env")
+
+	cases := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "the file wins over the flag and the env", args: []string{"--aws_secret", "from-flag", "--aws_secret_file", file}, want: "from-file"},
+		{name: "the flag wins over the env", args: []string{"--aws_secret", "from-flag"}, want: "from-flag"},
+		{name: "the env", want: "from-env"},
+	}
+
+	for _, tc := range cases {
+		set := flag.NewFlagSet(AWSLambdaCommand.Name, flag.ContinueOnError)
+		for _, f := range AWSLambdaCommand.Flags {
+			f.Apply(set)
+		}
+		if err := set.Parse(tc.args); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := readSecret(cli.NewContext(cli.NewApp(), set, nil), "aws_secret", "AWS_SECRET_ACCESS_KEY")
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	set := flag.NewFlagSet(AWSLambdaCommand.Name, flag.ContinueOnError)
+	for _, f := range AWSLambdaCommand.Flags {
+		f.Apply(set)
+	}
+	set.Parse([]string{"--aws_secret_file", filepath.Join(dir, "missing")})
+	if _, err := readSecret(cli.NewContext(cli.NewApp(), set, nil), "aws_secret", "AWS_SECRET_ACCESS_KEY"); err == nil {
+		t.Errorf("missing file: got no error")
+	}
+}
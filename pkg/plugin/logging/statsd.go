@@ -2,8 +2,12 @@ package logging
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/urfave/cli"
@@ -22,6 +26,26 @@ const (
 	PLUGIN_STATSD = "statsd"
 )
 
+//the stat types each metric can be logged as, docs: https://docs.konghq.com/hub/kong-inc/statsd/#metrics
+var statsdMetricStatTypes = map[string][]string{
+	"request_count":         {"counter", "gauge", "histogram", "meter", "timer"},
+	"request_size":          {"timer", "gauge", "histogram", "meter", "counter"},
+	"response_size":         {"timer", "gauge", "histogram", "meter", "counter"},
+	"latency":               {"timer", "gauge", "histogram", "meter", "counter"},
+	"upstream_latency":      {"timer", "gauge", "histogram", "meter", "counter"},
+	"kong_latency":          {"timer", "gauge", "histogram", "meter", "counter"},
+	"status_count":          {"counter"},
+	"unique_users":          {"set"},
+	"request_per_user":      {"counter"},
+	"status_count_per_user": {"counter"},
+}
+
+//the metrics that are logged per consumer and need a consumer_identifier.
+var statsdPerUserMetrics = []string{"unique_users", "request_per_user", "status_count_per_user"}
+
+//the consumer attributes a per consumer metric can be identified by.
+var statsdConsumerIdentifiers = []string{"consumer_id", "custom_id", "username"}
+
 type Statsd struct {
 	ID string `json:"ID,omitempty"`
 	//The name of the plugin to use, in this case statsd
@@ -29,7 +53,7 @@ type Statsd struct {
 	//Consumer_id is the id of the Consumer we want to associate with this plugin.
 	ConsumerID string       `json:"consumer_id,omitempty"`
 	ServiceID  string       `json:"service_id,omitempty"`
-	Enabled    bool         `json:"enabled,omitempty"`
+	Enabled    *bool        `json:"enabled,omitempty"`
 	Config     StatsDConfig `json:"config"`
 }
 
@@ -39,11 +63,22 @@ type StatsDConfig struct {
 	//The port to send data to on the upstream server
 	Port int `json:"port"`
 	//List of Metrics to be logged. Available values are described under Metrics.docs:https://docs.konghq.com/hub/kong-inc/statsd/#metrics
-	Metrics []string `json:"metrics,omitempty"`
+	Metrics []StatsDMetric `json:"metrics,omitempty"`
 	//String to be prefixed to each metric’s name.
 	Prefix string `json:"prefix,omitempty"`
 }
 
+type StatsDMetric struct {
+	//The metric name, see statsdMetricStatTypes.
+	Name string `json:"name"`
+	//Determines what sort of event the metric represents: gauge, timer, counter, histogram, meter or set.
+	StatType string `json:"stat_type"`
+	//The sample rate of counter and gauge metrics.
+	SampleRate float64 `json:"sample_rate,omitempty"`
+	//The consumer attribute per consumer metrics are identified by: consumer_id, custom_id or username.
+	ConsumerIdentifier string `json:"consumer_identifier,omitempty"`
+}
+
 var StatsDCommand = cli.Command{
 	Name:  "statsd",
 	Usage: "log metrics for a service, route to a StatsD server",
@@ -51,17 +86,37 @@ var StatsDCommand = cli.Command{
 		cli.StringFlag{Name: "host", Value: "127.0.0.1", Usage: "The IP address or host name to send data to"},
 		cli.IntFlag{Name: "port", Value: 8125, Usage: "The port to send data to on the upstream server"},
 		cli.StringFlag{Name: "prefix", Value: "kong", Usage: "String to be prefixed to each metric’s name."},
+		cli.StringSliceFlag{Name: "metric", Usage: "A metric to be logged, as name:stat_type[:sample_rate][:consumer_identifier], all the default metrics when empty"},
 	}...),
 	Action: createStatsDPlugin,
 }
 
+var StatsDUpdateCommand = cli.Command{
+	Name:  "statsd",
+	Usage: "edit the metrics of an existing statsd plugin",
+	Flags: []cli.Flag{
+		cli.StringFlag{Name: "id", Usage: "the statsd plugin id"},
+		cli.StringSliceFlag{Name: "metric", Usage: "Replace all the metrics, as name:stat_type[:sample_rate][:consumer_identifier]"},
+		cli.StringSliceFlag{Name: "add_metric", Usage: "Add a metric or replace the metric of the same name, as name:stat_type[:sample_rate][:consumer_identifier]"},
+		cli.StringSliceFlag{Name: "remove_metric", Usage: "Remove the metric of the name"},
+		cli.StringFlag{Name: "host", Usage: "The IP address or host name to send data to"},
+		cli.IntFlag{Name: "port", Usage: "The port to send data to on the upstream server"},
+		cli.StringFlag{Name: "prefix", Usage: "String to be prefixed to each metric’s name."},
+	},
+	Action: updateStatsDPlugin,
+}
+
 //createStatsDPlugin create a statsd pulgin for kong api gateway.
 func createStatsDPlugin(c *cli.Context) error {
 	name := c.String("name")
 	host := c.String("host")
 	port := c.Int("port")
 	prefix := c.String("prefix")
-	//TODO metrics use default data.
+
+	metrics, err := ParseStatsDMetrics(c.StringSlice("metric"))
+	if err != nil {
+		return err
+	}
 
 	serverID := c.String("service_id")
 	routeID := c.String("route_id")
@@ -80,15 +135,21 @@ func createStatsDPlugin(c *cli.Context) error {
 	}
 
 	config := StatsDConfig{
-		Host:   host,
-		Port:   port,
-		Prefix: prefix,
+		Host:    host,
+		Port:    port,
+		Prefix:  prefix,
+		Metrics: metrics,
+	}
+
+	if name == "" {
+		name = PLUGIN_STATSD
 	}
 
 	statsd := Statsd{
 		Name:       name,
 		Config:     config,
 		ConsumerID: consumerID,
+		Enabled:    utils.PluginEnabled(c),
 	}
 
 	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -107,3 +168,167 @@ func createStatsDPlugin(c *cli.Context) error {
 	tools.IndentFromBody(body)
 	return nil
 }
+
+//updateStatsDPlugin edit the metrics of a statsd plugin in place.
+func updateStatsDPlugin(c *cli.Context) error {
+	id := c.String("id")
+	if id == "" {
+		return fmt.Errorf("plugin id is empty")
+	}
+
+	requestURL := fmt.Sprintf("plugins/%s", id)
+
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	serverResponse, err := client.GatewayClient.Get(ctx, requestURL, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := ioutil.ReadAll(serverResponse.Body)
+	if err != nil {
+		return err
+	}
+
+	current := Statsd{}
+	if err = json.Unmarshal(body, &current); err != nil {
+		return err
+	}
+
+	if current.Name != PLUGIN_STATSD {
+		return fmt.Errorf("plugin %s is %s, not a statsd plugin", id, current.Name)
+	}
+
+	metrics := current.Config.Metrics
+	if c.IsSet("metric") {
+		if metrics, err = ParseStatsDMetrics(c.StringSlice("metric")); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range c.StringSlice("remove_metric") {
+		metrics = removeStatsDMetric(metrics, name)
+	}
+
+	added, err := ParseStatsDMetrics(c.StringSlice("add_metric"))
+	if err != nil {
+		return err
+	}
+	for _, m := range added {
+		metrics = append(removeStatsDMetric(metrics, m.Name), m)
+	}
+
+	if len(metrics) == 0 {
+		return fmt.Errorf("the statsd plugin needs at least one metric")
+	}
+
+	config := map[string]interface{}{"metrics": metrics}
+	if c.IsSet("host") {
+		config["host"] = c.String("host")
+	}
+	if c.IsSet("port") {
+		config["port"] = c.Int("port")
+	}
+	if c.IsSet("prefix") {
+		config["prefix"] = c.String("prefix")
+	}
+
+	serverResponse, err = client.GatewayClient.PATCH(ctx, requestURL, nil, map[string]interface{}{"config": config}, nil)
+	if err != nil {
+		return err
+	}
+
+	body, err = ioutil.ReadAll(serverResponse.Body)
+	if err != nil {
+		return err
+	}
+
+	tools.IndentFromBody(body)
+	return nil
+}
+
+func removeStatsDMetric(metrics []StatsDMetric, name string) []StatsDMetric {
+	result := make([]StatsDMetric, 0, len(metrics))
+	for _, m := range metrics {
+		if m.Name != name {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+//ParseStatsDMetrics parse metrics written as name:stat_type[:sample_rate][:consumer_identifier].
+func ParseStatsDMetrics(values []string) ([]StatsDMetric, error) {
+	var metrics []StatsDMetric
+
+	for _, v := range values {
+		parts := strings.Split(v, ":")
+		if len(parts) < 2 || len(parts) > 4 {
+			return nil, fmt.Errorf("metric %q is invalid, expected name:stat_type[:sample_rate][:consumer_identifier]", v)
+		}
+
+		m := StatsDMetric{Name: parts[0], StatType: parts[1]}
+		for _, p := range parts[2:] {
+			if p == "" {
+				continue
+			}
+			if rate, err := strconv.ParseFloat(p, 64); err == nil {
+				m.SampleRate = rate
+			} else {
+				m.ConsumerIdentifier = p
+			}
+		}
+
+		if err := ValidateStatsDMetric(m); err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+
+	return metrics, nil
+}
+
+//ValidateStatsDMetric check a metric against the documented metric/stat type matrix.
+func ValidateStatsDMetric(m StatsDMetric) error {
+	statTypes, ok := statsdMetricStatTypes[m.Name]
+	if !ok {
+		names := make([]string, 0, len(statsdMetricStatTypes))
+		for name := range statsdMetricStatTypes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("metric %s is unknown, available metrics are %s", m.Name, strings.Join(names, ", "))
+	}
+
+	if !contains(statTypes, m.StatType) {
+		return fmt.Errorf("metric %s can not be a %s, available stat types are %s", m.Name, m.StatType, strings.Join(statTypes, ", "))
+	}
+
+	if m.StatType == "counter" || m.StatType == "gauge" {
+		if m.SampleRate <= 0 || m.SampleRate > 1 {
+			return fmt.Errorf("metric %s is a %s and needs a sample_rate in (0,1]", m.Name, m.StatType)
+		}
+	} else if m.SampleRate != 0 {
+		return fmt.Errorf("metric %s is a %s and does not take a sample_rate", m.Name, m.StatType)
+	}
+
+	if contains(statsdPerUserMetrics, m.Name) {
+		if !contains(statsdConsumerIdentifiers, m.ConsumerIdentifier) {
+			return fmt.Errorf("metric %s needs a consumer_identifier: %s", m.Name, strings.Join(statsdConsumerIdentifiers, ", "))
+		}
+	} else if m.ConsumerIdentifier != "" {
+		return fmt.Errorf("metric %s is not a per consumer metric and does not take a consumer_identifier", m.Name)
+	}
+
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, l := range list {
+		if l == value {
+			return true
+		}
+	}
+	return false
+}
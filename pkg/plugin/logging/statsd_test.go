@@ -0,0 +1,122 @@
+package logging
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/common/client"
+)
+
+func TestParseStatsDMetrics(t *testing.T) {
+	cases := []struct {
+		value   string
+		want    StatsDMetric
+		wantErr bool
+	}{
+		{value: "latency:timer", want: StatsDMetric{Name: "latency", StatType: "timer"}},
+		{value: "request_count:counter:0.5", want: StatsDMetric{Name: "request_count", StatType: "counter", SampleRate: 0.5}},
+		{value: "request_size:gauge:1", want: StatsDMetric{Name: "request_size", StatType: "gauge", SampleRate: 1}},
+		{value: "unique_users:set::username", want: StatsDMetric{Name: "unique_users", StatType: "set", ConsumerIdentifier: "username"}},
+		{value: "request_per_user:counter:1:custom_id", want: StatsDMetric{Name: "request_per_user", StatType: "counter", SampleRate: 1, ConsumerIdentifier: "custom_id"}},
+		{value: "latency", wantErr: true},
+		{value: "latency:timer:1:username:x", wantErr: true},
+		{value: "upstream_health:gauge:1", wantErr: true},
+		//unique_users is only a set.
+		{value: "unique_users:counter:1:username", wantErr: true},
+		//status_count is only a counter.
+		{value: "status_count:gauge:1", wantErr: true},
+		{value: "status_count:timer", wantErr: true},
+		//counters and gauges need a sample_rate in (0,1].
+		{value: "request_count:counter", wantErr: true},
+		{value: "request_count:counter:0", wantErr: true},
+		{value: "request_count:gauge:1.5", wantErr: true},
+		{value: "latency:timer:0.5", wantErr: true},
+		//per user metrics need a consumer_identifier, the others take none.
+		{value: "unique_users:set", wantErr: true},
+		{value: "status_count_per_user:counter:1:email", wantErr: true},
+		{value: "latency:timer::username", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		got, err := ParseStatsDMetrics([]string{tc.value})
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tc.value, err, tc.wantErr)
+			continue
+		}
+		if !tc.wantErr && !reflect.DeepEqual(got, []StatsDMetric{tc.want}) {
+			t.Errorf("%s: got %+v, want %+v", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestRemoveStatsDMetric(t *testing.T) {
+	metrics := []StatsDMetric{
+		{Name: "latency", StatType: "timer"},
+		{Name: "request_count", StatType: "counter", SampleRate: 1},
+	}
+
+	got := removeStatsDMetric(metrics, "latency")
+	want := []StatsDMetric{{Name: "request_count", StatType: "counter", SampleRate: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if got := removeStatsDMetric(metrics, "kong_latency"); !reflect.DeepEqual(got, metrics) {
+		t.Errorf("unknown metric: got %+v, want %+v", got, metrics)
+	}
+}
+
+func TestUpdateStatsDPluginAddMetric(t *testing.T) {
+	var patched struct {
+		Config struct {
+			Metrics []StatsDMetric `json:"metrics"`
+		} `json:"config"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"id":"p1","name":"statsd","config":{"metrics":[{"name":"latency","stat_type":"timer"},{"name":"request_count","stat_type":"counter","sample_rate":1}]}}`))
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &patched); err != nil {
+			t.Errorf("%s: %v", body, err)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	old := client.GatewayClient
+	defer func() { client.GatewayClient = old }()
+	var err error
+	if client.GatewayClient, err = client.NewHTTPClient(server.URL, map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	set := flag.NewFlagSet(StatsDUpdateCommand.Name, flag.ContinueOnError)
+	for _, f := range StatsDUpdateCommand.Flags {
+		f.Apply(set)
+	}
+	if err := set.Parse([]string{"--id", "p1", "--add_metric", "latency:gauge:0.5"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := updateStatsDPlugin(cli.NewContext(cli.NewApp(), set, nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	//the added metric replaces the metric of the same name.
+	want := []StatsDMetric{
+		{Name: "request_count", StatType: "counter", SampleRate: 1},
+		{Name: "latency", StatType: "gauge", SampleRate: 0.5},
+	}
+	if !reflect.DeepEqual(patched.Config.Metrics, want) {
+		t.Errorf("got %+v, want %+v", patched.Config.Metrics, want)
+	}
+}
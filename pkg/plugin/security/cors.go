@@ -1 +1,254 @@
 package security
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/common/client"
+	"github.com/xigang/kongctl/common/tools"
+	"github.com/xigang/kongctl/pkg/plugin/utils"
+)
+
+//CORS
+//https://docs.konghq.com/hub/kong-inc/cors/
+
+//Easily add Cross-origin resource sharing (CORS) to a Service, a Route by enabling this plugin.
+
+const (
+	PLUGIN_CORS = "cors"
+)
+
+var (
+	//the methods kong allows when config.methods is empty.
+	defaultCORSMethods = []string{"GET", "HEAD", "PUT", "PATCH", "POST"}
+	//origins made of these characters only are not treated as a regex.
+	plainOriginRegexp = regexp.MustCompile(`^[A-Za-z0-9.:/-]+$`)
+)
+
+type CORS struct {
+	//The name of the plugin to use, in this case cors
+	Name string `json:"name"`
+	//Consumer_id is the id of the Consumer we want to associate with this plugin.
+	ConsumerID string     `json:"consumer_id,omitempty"`
+	Enabled    *bool      `json:"enabled,omitempty"`
+	Config     CORSConfig `json:"config"`
+}
+
+type CORSConfig struct {
+	//List of allowed domains for the Access-Control-Allow-Origin header. If you wish to allow all origins, add * as a single value to this configuration field. The accepted values can either be flat strings or PCRE regexes.
+	Origins []string `json:"origins,omitempty"`
+	//Value for the Access-Control-Allow-Methods header, expects a comma delimited string (e.g. GET,POST). Defaults to GET,HEAD,PUT,PATCH,POST.
+	Methods []string `json:"methods,omitempty"`
+	//Value for the Access-Control-Allow-Headers header, expects a comma delimited string (e.g. Origin, Authorization). Defaults to the value of the Access-Control-Request-Headers header.
+	Headers []string `json:"headers,omitempty"`
+	//Value for the Access-Control-Expose-Headers header, expects a comma delimited string (e.g. Origin, Authorization). If not specified, no custom headers are exposed.
+	ExposedHeaders []string `json:"exposed_headers,omitempty"`
+	//Flag to determine whether the Access-Control-Allow-Credentials header should be sent with true as the value.
+	Credentials bool `json:"credentials"`
+	//Indicated how long the results of the preflight request can be cached, in seconds.
+	MaxAge int `json:"max_age,omitempty"`
+	//A boolean value that instructs the plugin to proxy the OPTIONS preflight request to the upstream service.
+	PreflightContinue bool `json:"preflight_continue"`
+}
+
+var CORSCommand = cli.Command{
+	Name:  "cors",
+	Usage: "add cross-origin resource sharing (cors) to a service or a route",
+	Flags: append(utils.CommonPluginFlags, []cli.Flag{
+		cli.StringSliceFlag{Name: "origins", Usage: "List of allowed domains for the Access-Control-Allow-Origin header, flat strings or PCRE regexes"},
+		cli.StringSliceFlag{Name: "methods", Usage: "Value for the Access-Control-Allow-Methods header (default: GET,HEAD,PUT,PATCH,POST)"},
+		cli.StringSliceFlag{Name: "headers", Usage: "Value for the Access-Control-Allow-Headers header"},
+		cli.StringSliceFlag{Name: "exposed_headers", Usage: "Value for the Access-Control-Expose-Headers header"},
+		cli.BoolFlag{Name: "credentials", Usage: "Send the Access-Control-Allow-Credentials header with true as the value"},
+		cli.IntFlag{Name: "max_age", Usage: "Indicated how long the results of the preflight request can be cached, in seconds"},
+		cli.BoolFlag{Name: "preflight_continue", Usage: "Proxy the OPTIONS preflight request to the upstream service"},
+	}...),
+	Action: createCORSPlugin,
+}
+
+//createCORSPlugin create a cors plugin for kong api gateway.
+func createCORSPlugin(c *cli.Context) error {
+	config := CORSConfig{
+		Origins:           splitList(c.StringSlice("origins")),
+		Methods:           splitList(c.StringSlice("methods")),
+		Headers:           splitList(c.StringSlice("headers")),
+		ExposedHeaders:    splitList(c.StringSlice("exposed_headers")),
+		Credentials:       c.Bool("credentials"),
+		MaxAge:            c.Int("max_age"),
+		PreflightContinue: c.Bool("preflight_continue"),
+	}
+
+	for _, origin := range config.Origins {
+		if _, err := regexp.Compile(origin); err != nil {
+			return fmt.Errorf("origin %s is not a valid regex: %v", origin, err)
+		}
+	}
+
+	if config.MaxAge < 0 {
+		return fmt.Errorf("max_age %d is invalid", config.MaxAge)
+	}
+
+	requestURL := utils.PluginRequestURL(c.String("service_id"), c.String("route_id"))
+
+	cors := CORS{
+		Name:       PLUGIN_CORS,
+		ConsumerID: c.String("consumer_id"),
+		Enabled:    utils.PluginEnabled(c),
+		Config:     config,
+	}
+
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	serverResponse, err := client.GatewayClient.Post(ctx, requestURL, nil, cors, nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := ioutil.ReadAll(serverResponse.Body)
+	if err != nil {
+		return err
+	}
+
+	tools.IndentFromBody(body)
+	return nil
+}
+
+//splitList accept both repeated flags and comma delimited values.
+func splitList(values []string) []string {
+	var list []string
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+//CORSHeader is a response header produced by the cors plugin.
+type CORSHeader struct {
+	Name  string
+	Value string
+}
+
+//CORSSimulation is the outcome of evaluating a cors config against a browser request.
+type CORSSimulation struct {
+	//Whether the request is a OPTIONS preflight request.
+	Preflight bool
+	//The headers kong would add to the response.
+	Headers []CORSHeader
+	//Whether the preflight request is proxied to the upstream service instead of answered by kong.
+	Proxied bool
+	//Whether the browser would accept the response, and the reason when it does not.
+	Allowed bool
+	Reason  string
+}
+
+//SimulateCORS evaluate a cors config locally, the same way the kong cors plugin does, for a request
+//with the given origin. When preflight is set, method is the Access-Control-Request-Method of the
+//OPTIONS request and requestHeaders its Access-Control-Request-Headers.
+func SimulateCORS(config CORSConfig, origin, method string, requestHeaders []string, preflight bool) CORSSimulation {
+	sim := CORSSimulation{Preflight: preflight}
+
+	allowOrigin := configureOrigin(config, origin, &sim)
+	if config.Credentials {
+		sim.Headers = append(sim.Headers, CORSHeader{"Access-Control-Allow-Credentials", "true"})
+	}
+
+	methods := config.Methods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+
+	if preflight {
+		allowHeaders := config.Headers
+		if len(allowHeaders) == 0 {
+			allowHeaders = requestHeaders
+		}
+		if len(allowHeaders) > 0 {
+			sim.Headers = append(sim.Headers, CORSHeader{"Access-Control-Allow-Headers", strings.Join(allowHeaders, ",")})
+		}
+		sim.Headers = append(sim.Headers, CORSHeader{"Access-Control-Allow-Methods", strings.Join(methods, ",")})
+		if config.MaxAge > 0 {
+			sim.Headers = append(sim.Headers, CORSHeader{"Access-Control-Max-Age", strconv.Itoa(config.MaxAge)})
+		}
+		sim.Proxied = config.PreflightContinue
+	} else if len(config.ExposedHeaders) > 0 {
+		sim.Headers = append(sim.Headers, CORSHeader{"Access-Control-Expose-Headers", strings.Join(config.ExposedHeaders, ",")})
+	}
+
+	switch {
+	case allowOrigin == "":
+		sim.Reason = fmt.Sprintf("origin %s does not match any configured origin", origin)
+	case allowOrigin != "*" && allowOrigin != origin:
+		sim.Reason = fmt.Sprintf("Access-Control-Allow-Origin %s does not match origin %s", allowOrigin, origin)
+	case allowOrigin == "*" && config.Credentials:
+		sim.Reason = "browsers reject a wildcard Access-Control-Allow-Origin on credentialed requests"
+	case preflight && !containsFold(methods, method):
+		sim.Reason = fmt.Sprintf("method %s is not in Access-Control-Allow-Methods", method)
+	case preflight && len(config.Headers) > 0 && !containsAllFold(config.Headers, requestHeaders):
+		sim.Reason = fmt.Sprintf("request headers %s are not all in Access-Control-Allow-Headers", strings.Join(requestHeaders, ","))
+	default:
+		sim.Allowed = true
+	}
+
+	return sim
+}
+
+//configureOrigin mirror the configure_origin function of the kong cors plugin and return the Access-Control-Allow-Origin value.
+func configureOrigin(config CORSConfig, origin string, sim *CORSSimulation) string {
+	allowOrigin := ""
+
+	switch {
+	case len(config.Origins) == 0:
+		allowOrigin = "*"
+	case len(config.Origins) == 1 && config.Origins[0] == "*":
+		allowOrigin = "*"
+	case len(config.Origins) == 1 && plainOriginRegexp.MatchString(config.Origins[0]):
+		allowOrigin = config.Origins[0]
+	default:
+		for _, o := range config.Origins {
+			re, err := regexp.Compile(o + "$")
+			if err == nil && origin != "" && re.MatchString(origin) {
+				allowOrigin = origin
+				break
+			}
+		}
+	}
+
+	if allowOrigin != "" {
+		sim.Headers = append(sim.Headers, CORSHeader{"Access-Control-Allow-Origin", allowOrigin})
+	}
+	if allowOrigin != "*" {
+		sim.Headers = append(sim.Headers, CORSHeader{"Vary", "Origin"})
+	}
+
+	return allowOrigin
+}
+
+func containsFold(list []string, value string) bool {
+	for _, l := range list {
+		if strings.EqualFold(l, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAllFold(list []string, values []string) bool {
+	for _, v := range values {
+		if !containsFold(list, v) {
+			return false
+		}
+	}
+	return true
+}
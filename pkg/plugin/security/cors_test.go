@@ -0,0 +1,142 @@
+package security
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xigang/kongctl/pkg/plugin/utils/plugintest"
+)
+
+func TestCreateCORSPlugin(t *testing.T) {
+	cases := []struct {
+		args []string
+		want map[string]interface{}
+	}{
+		{
+			args: []string{"--origins", "https://example.com"},
+			want: map[string]interface{}{"name": "cors"},
+		},
+		{
+			args: []string{"--origins", "https://example.com", "--consumer_id", "c1", "--enabled=false"},
+			want: map[string]interface{}{"name": "cors", "consumer_id": "c1", "enabled": false},
+		},
+	}
+
+	for _, tc := range cases {
+		got := plugintest.PostedPlugin(t, CORSCommand, tc.args...)
+		delete(got, "config")
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%v: got %v, want %v", tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestSimulateCORS(t *testing.T) {
+	cases := []struct {
+		name           string
+		config         CORSConfig
+		origin, method string
+		requestHeaders []string
+		preflight      bool
+		allowed        bool
+		proxied        bool
+		allowOrigin    string
+	}{
+		{
+			name:        "no origins allow every origin",
+			config:      CORSConfig{},
+			origin:      "https://app.example.com",
+			method:      "GET",
+			allowed:     true,
+			allowOrigin: "*",
+		},
+		{
+			name:        "a single plain origin is sent as is",
+			config:      CORSConfig{Origins: []string{"https://app.example.com"}},
+			origin:      "https://evil.example.org",
+			method:      "GET",
+			allowOrigin: "https://app.example.com",
+		},
+		{
+			name:        "a regex origin echoes the matching origin",
+			config:      CORSConfig{Origins: []string{`https://.*\.example\.com`, "https://other.org"}},
+			origin:      "https://api.example.com",
+			method:      "GET",
+			allowed:     true,
+			allowOrigin: "https://api.example.com",
+		},
+		{
+			name:   "no regex origin matches",
+			config: CORSConfig{Origins: []string{`https://.*\.example\.com`, "https://other.org"}},
+			origin: "https://api.example.com.evil.org",
+			method: "GET",
+		},
+		{
+			name:        "a wildcard origin with credentials",
+			config:      CORSConfig{Credentials: true},
+			origin:      "https://app.example.com",
+			method:      "GET",
+			allowOrigin: "*",
+		},
+		{
+			name:        "a preflight method outside the default methods",
+			config:      CORSConfig{},
+			origin:      "https://app.example.com",
+			method:      "DELETE",
+			preflight:   true,
+			allowOrigin: "*",
+		},
+		{
+			name:           "a preflight header outside the allowed headers",
+			config:         CORSConfig{Headers: []string{"X-Request-Id"}},
+			origin:         "https://app.example.com",
+			method:         "GET",
+			requestHeaders: []string{"x-request-id", "Authorization"},
+			preflight:      true,
+			allowOrigin:    "*",
+		},
+		{
+			name:           "a preflight proxied upstream",
+			config:         CORSConfig{Methods: []string{"GET", "DELETE"}, PreflightContinue: true},
+			origin:         "https://app.example.com",
+			method:         "delete",
+			requestHeaders: []string{"Authorization"},
+			preflight:      true,
+			allowed:        true,
+			proxied:        true,
+			allowOrigin:    "*",
+		},
+	}
+
+	for _, tc := range cases {
+		sim := SimulateCORS(tc.config, tc.origin, tc.method, tc.requestHeaders, tc.preflight)
+
+		allowOrigin := ""
+		for _, h := range sim.Headers {
+			if h.Name == "Access-Control-Allow-Origin" {
+				allowOrigin = h.Value
+			}
+		}
+
+		if sim.Allowed != tc.allowed || sim.Proxied != tc.proxied || allowOrigin != tc.allowOrigin {
+			t.Errorf("%s: got allowed %v (%s), proxied %v, origin %q", tc.name, sim.Allowed, sim.Reason, sim.Proxied, allowOrigin)
+		}
+	}
+}
+
+func TestSimulateCORSPreflightHeaders(t *testing.T) {
+	config := CORSConfig{Origins: []string{"https://app.example.com"}, MaxAge: 3600, Credentials: true}
+	sim := SimulateCORS(config, "https://app.example.com", "POST", []string{"Content-Type"}, true)
+
+	want := []CORSHeader{
+		{"Access-Control-Allow-Origin", "https://app.example.com"},
+		{"Vary", "Origin"},
+		{"Access-Control-Allow-Credentials", "true"},
+		{"Access-Control-Allow-Headers", "Content-Type"},
+		{"Access-Control-Allow-Methods", "GET,HEAD,PUT,PATCH,POST"},
+		{"Access-Control-Max-Age", "3600"},
+	}
+	if !reflect.DeepEqual(sim.Headers, want) {
+		t.Errorf("got %v, want %v", sim.Headers, want)
+	}
+}
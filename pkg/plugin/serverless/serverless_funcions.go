@@ -1 +1,287 @@
 package serverless
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/common/client"
+	"github.com/xigang/kongctl/common/tools"
+	"github.com/xigang/kongctl/pkg/plugin/utils"
+)
+
+//Serverless Functions
+//https://docs.konghq.com/hub/kong-inc/serverless-functions/
+
+//Dynamically run Lua code from Kong, before other plugins (pre-function) or after other plugins (post-function) in each phase.
+
+const (
+	PLUGIN_PRE_FUNCTION  = "pre-function"
+	PLUGIN_POST_FUNCTION = "post-function"
+)
+
+type ServerlessFunction struct {
+	//The name of the plugin to use, in this case pre-function or post-function
+	Name string `json:"name"`
+	//Consumer_id is the id of the Consumer we want to associate with this plugin.
+	ConsumerID string          `json:"consumer_id,omitempty"`
+	Enabled    *bool           `json:"enabled,omitempty"`
+	Config     FunctionsConfig `json:"config"`
+}
+
+type FunctionsConfig struct {
+	//Array of stringified Lua code to be cached and run in sequence during access phase.
+	Functions []string `json:"functions"`
+}
+
+var serverlessFlags = append(utils.CommonPluginFlags, []cli.Flag{
+	cli.StringSliceFlag{Name: "file", Usage: "A lua file to run, repeat the flag to run several functions in sequence"},
+}...)
+
+var PreFunctionCommand = cli.Command{
+	Name:   "pre-function",
+	Usage:  "run lua code before other plugins in the access phase",
+	Flags:  serverlessFlags,
+	Action: createPreFunctionPlugin,
+}
+
+var PostFunctionCommand = cli.Command{
+	Name:   "post-function",
+	Usage:  "run lua code after other plugins in the access phase",
+	Flags:  serverlessFlags,
+	Action: createPostFunctionPlugin,
+}
+
+func createPreFunctionPlugin(c *cli.Context) error {
+	return createServerlessPlugin(c, PLUGIN_PRE_FUNCTION)
+}
+
+func createPostFunctionPlugin(c *cli.Context) error {
+	return createServerlessPlugin(c, PLUGIN_POST_FUNCTION)
+}
+
+//createServerlessPlugin upload the lua files as the functions of a pre-function or post-function plugin.
+func createServerlessPlugin(c *cli.Context, name string) error {
+	files := c.StringSlice("file")
+	if len(files) == 0 {
+		return fmt.Errorf("at least one lua file must be specified")
+	}
+
+	config := FunctionsConfig{}
+	for _, file := range files {
+		code, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		if err := CheckLua(string(code)); err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+		config.Functions = append(config.Functions, string(code))
+	}
+
+	requestURL := utils.PluginRequestURL(c.String("service_id"), c.String("route_id"))
+
+	function := ServerlessFunction{
+		Name:       name,
+		ConsumerID: c.String("consumer_id"),
+		Enabled:    utils.PluginEnabled(c),
+		Config:     config,
+	}
+
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	serverResponse, err := client.GatewayClient.Post(ctx, requestURL, nil, function, nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := ioutil.ReadAll(serverResponse.Body)
+	if err != nil {
+		return err
+	}
+
+	tools.IndentFromBody(body)
+	return nil
+}
+
+//ExtractFunctions write every function of a serverless plugin to <dir>/<prefix>-<n>.lua and return the file names.
+func ExtractFunctions(config FunctionsConfig, dir, prefix string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for i, code := range config.Functions {
+		file := filepath.Join(dir, fmt.Sprintf("%s-%d.lua", prefix, i+1))
+		if err := ioutil.WriteFile(file, []byte(code), 0644); err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+//CheckLua run basic sanity checks on lua code: the body is not empty, and the blocks,
+//brackets and strings are balanced. It is not a parser, kong still reports real syntax errors.
+func CheckLua(code string) error {
+	tokens, err := luaTokens(code)
+	if err != nil {
+		return err
+	}
+
+	if len(tokens) == 0 {
+		return fmt.Errorf("the lua code is empty")
+	}
+
+	var blocks []string
+	for _, t := range tokens {
+		switch t.text {
+		case "function", "if", "do", "repeat", "(", "[", "{":
+			blocks = append(blocks, t.text)
+		case "end", "until", ")", "]", "}":
+			if len(blocks) == 0 {
+				return fmt.Errorf("line %d: unexpected '%s'", t.line, t.text)
+			}
+
+			open := blocks[len(blocks)-1]
+			if closer(open) != t.text {
+				return fmt.Errorf("line %d: '%s' closes a '%s' block", t.line, t.text, open)
+			}
+			blocks = blocks[:len(blocks)-1]
+		}
+	}
+
+	if len(blocks) > 0 {
+		return fmt.Errorf("%d unclosed block(s), the last one is '%s'", len(blocks), blocks[len(blocks)-1])
+	}
+
+	return nil
+}
+
+func closer(open string) string {
+	switch open {
+	case "repeat":
+		return "until"
+	case "(":
+		return ")"
+	case "[":
+		return "]"
+	case "{":
+		return "}"
+	default:
+		return "end"
+	}
+}
+
+type luaToken struct {
+	text string
+	line int
+}
+
+//luaTokens return the keywords, names and brackets of lua code, comments and strings are skipped.
+func luaTokens(code string) ([]luaToken, error) {
+	var tokens []luaToken
+	line := 1
+
+	for i := 0; i < len(code); {
+		ch := code[i]
+
+		switch {
+		case ch == '\n':
+			line++
+			i++
+		case ch == '-' && strings.HasPrefix(code[i:], "--"):
+			//long comment --[[ ]] or --[==[ ]==], otherwise a line comment.
+			if level, ok := longBracket(code[i+2:]); ok {
+				end, err := skipLongBracket(code, i+2, level)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: unfinished long comment", line)
+				}
+				line += strings.Count(code[i:end], "\n")
+				i = end
+				continue
+			}
+			for i < len(code) && code[i] != '\n' {
+				i++
+			}
+		case ch == '"' || ch == '\'':
+			j := i + 1
+			for ; j < len(code) && code[j] != ch; j++ {
+				if code[j] == '\\' {
+					j++
+				} else if code[j] == '\n' {
+					return nil, fmt.Errorf("line %d: unfinished string", line)
+				}
+			}
+			if j >= len(code) {
+				return nil, fmt.Errorf("line %d: unfinished string", line)
+			}
+			i = j + 1
+		case ch == '[':
+			if level, ok := longBracket(code[i:]); ok {
+				end, err := skipLongBracket(code, i, level)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: unfinished long string", line)
+				}
+				line += strings.Count(code[i:end], "\n")
+				i = end
+				continue
+			}
+			tokens = append(tokens, luaToken{"[", line})
+			i++
+		case strings.IndexByte("()]{}", ch) >= 0:
+			tokens = append(tokens, luaToken{string(ch), line})
+			i++
+		case ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z':
+			j := i
+			for j < len(code) && (code[j] == '_' || code[j] >= 'a' && code[j] <= 'z' || code[j] >= 'A' && code[j] <= 'Z' || code[j] >= '0' && code[j] <= '9') {
+				j++
+			}
+			tokens = append(tokens, luaToken{code[i:j], line})
+			i = j
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			i++
+		default:
+			tokens = append(tokens, luaToken{string(ch), line})
+			i++
+		}
+	}
+
+	return tokens, nil
+}
+
+//longBracket report whether s starts with a lua long bracket [[ or [=*[ and return its level.
+func longBracket(s string) (int, bool) {
+	if !strings.HasPrefix(s, "[") {
+		return 0, false
+	}
+
+	level := 0
+	for level+1 < len(s) && s[level+1] == '=' {
+		level++
+	}
+	if level+1 < len(s) && s[level+1] == '[' {
+		return level, true
+	}
+	return 0, false
+}
+
+//skipLongBracket return the index after the long bracket of the level opened at code[start].
+func skipLongBracket(code string, start, level int) (int, error) {
+	closing := "]" + strings.Repeat("=", level) + "]"
+
+	end := strings.Index(code[start+level+2:], closing)
+	if end < 0 {
+		return 0, fmt.Errorf("unfinished long bracket")
+	}
+	return start + level + 2 + end + len(closing), nil
+}
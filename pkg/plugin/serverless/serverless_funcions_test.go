@@ -0,0 +1,68 @@
+package serverless
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckLua(t *testing.T) {
+	cases := []struct {
+		name string
+		code string
+		err  string
+	}{
+		{name: "a function", code: "local function f(a)\n  if a then return {a} end\nend\nreturn f"},
+		{name: "loops", code: "for i = 1, 3 do\n  while true do break end\nend\nrepeat x = x + 1 until x > 3"},
+		{name: "keywords in strings and comments", code: "-- end\nlocal s = \"end)\" .. 'if' .. [[\nfunction\n]]\n--[==[ do ]==]\nngx.say(s)"},
+		{name: "an escaped quote", code: `ngx.say("a \" end")`},
+		{name: "empty", code: "  \n-- only a comment\n", err: "the lua code is empty"},
+		{name: "a missing end", code: "if ngx.var.uri then\n  ngx.exit(403)\n", err: "1 unclosed block(s), the last one is 'if'"},
+		{name: "an extra end", code: "ngx.exit(403)\nend", err: "line 2: unexpected 'end'"},
+		{name: "mismatched brackets", code: "local t = {1, 2)\n", err: "line 1: ')' closes a '{' block"},
+		{name: "until closing a function", code: "local f = function()\nuntil", err: "line 2: 'until' closes a 'function' block"},
+		{name: "an unfinished string", code: "ngx.say(\"hello)\nngx.exit(200)", err: "line 1: unfinished string"},
+		{name: "an unfinished long string", code: "local s = [==[\nhello\n]=]", err: "line 1: unfinished long string"},
+		{name: "an unfinished long comment", code: "\n--[[ hello", err: "line 2: unfinished long comment"},
+		{name: "lines are counted across long strings", code: "local s = [[\n\n]]\nend", err: "line 4: unexpected 'end'"},
+	}
+
+	for _, tc := range cases {
+		err := CheckLua(tc.code)
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%s: got error %v", tc.name, err)
+		case tc.err != "" && (err == nil || err.Error() != tc.err):
+			t.Errorf("%s: got error %v, want %s", tc.name, err, tc.err)
+		}
+	}
+}
+
+func TestExtractFunctions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kongctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := FunctionsConfig{Functions: []string{"ngx.say('a')", "ngx.say('b')"}}
+	files, err := ExtractFunctions(config, filepath.Join(dir, "functions"), "pre-function")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 2 || !strings.HasSuffix(files[1], "pre-function-2.lua") {
+		t.Fatalf("got files %v", files)
+	}
+	for i, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != config.Functions[i] {
+			t.Errorf("%s: got %q, want %q", file, data, config.Functions[i])
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package traffic_control
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/common/client"
+	"github.com/xigang/kongctl/common/tools"
+	"github.com/xigang/kongctl/pkg/plugin/utils"
+)
+
+//ACL
+//https://docs.konghq.com/hub/kong-inc/acl/
+
+//Restrict access to a Service or a Route by whitelisting or blacklisting consumers using arbitrary ACL group names.
+//This plugin requires an authentication plugin to have been already enabled on the Service or Route.
+
+const (
+	PLUGIN_ACL = "acl"
+
+	ACL_GROUPS_PAGE_SIZE = "1000"
+)
+
+type ACL struct {
+	//The name of the plugin to use, in this case acl
+	Name string `json:"name"`
+	//Consumer_id is the id of the Consumer we want to associate with this plugin.
+	ConsumerID string    `json:"consumer_id,omitempty"`
+	Enabled    *bool     `json:"enabled,omitempty"`
+	Config     ACLConfig `json:"config"`
+}
+
+type ACLConfig struct {
+	//Comma separated list of arbitrary group names that are allowed to consume the Service or the Route. One of config.whitelist or config.blacklist must be specified.
+	Whitelist []string `json:"whitelist,omitempty"`
+	//Comma separated list of arbitrary group names that are not allowed to consume the Service or the Route. One of config.whitelist or config.blacklist must be specified.
+	Blacklist []string `json:"blacklist,omitempty"`
+	//Flag which if enabled (true), prevents the X-Consumer-Groups header to be sent in the request to the upstream service.
+	HideGroupsHeader bool `json:"hide_groups_header"`
+}
+
+type ACLGroups struct {
+	Data []ACLGroup `json:"data"`
+	//The offset of the next page, empty on the last page.
+	Offset string `json:"offset,omitempty"`
+}
+
+type ACLGroup struct {
+	ID         string `json:"id,omitempty"`
+	ConsumerID string `json:"consumer_id,omitempty"`
+	//The arbitrary group name to associate to the consumer.
+	Group string `json:"group"`
+}
+
+var ACLCommand = cli.Command{
+	Name:  "acl",
+	Usage: "restrict access to a service or a route by whitelisting or blacklisting consumers",
+	Flags: append(utils.CommonPluginFlags, []cli.Flag{
+		cli.StringSliceFlag{Name: "whitelist", Usage: "arbitrary group names that are allowed to consume the service or the route"},
+		cli.StringSliceFlag{Name: "blacklist", Usage: "arbitrary group names that are not allowed to consume the service or the route"},
+		cli.BoolFlag{Name: "hide_groups_header", Usage: "prevents the X-Consumer-Groups header to be sent in the request to the upstream service"},
+	}...),
+	Action: createACLPlugin,
+}
+
+var aclGroupFlags = []cli.Flag{
+	cli.StringFlag{Name: "consumer", Usage: "the consumer id or username"},
+	cli.StringFlag{Name: "group", Usage: "the arbitrary group name to associate to the consumer"},
+}
+
+//ConsumerACLCommand manage the acl groups a consumer belongs to.
+var ConsumerACLCommand = cli.Command{
+	Name:  "acl",
+	Usage: "manage the acl groups of a consumer",
+	Subcommands: []cli.Command{
+		{
+			Name:   "add",
+			Usage:  "associate a group to a consumer",
+			Flags:  aclGroupFlags,
+			Action: addConsumerACLGroup,
+		},
+		{
+			Name:   "remove",
+			Usage:  "remove a group from a consumer",
+			Flags:  aclGroupFlags,
+			Action: removeConsumerACLGroup,
+		},
+		{
+			Name:  "list",
+			Usage: "list the groups of a consumer",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "consumer", Usage: "the consumer id or username"},
+			},
+			Action: listConsumerACLGroups,
+		},
+	},
+}
+
+//createACLPlugin create a acl plugin for kong api gateway.
+func createACLPlugin(c *cli.Context) error {
+	whitelist := c.StringSlice("whitelist")
+	blacklist := c.StringSlice("blacklist")
+
+	if len(whitelist) == 0 && len(blacklist) == 0 {
+		return fmt.Errorf("one of whitelist or blacklist must be specified")
+	}
+
+	if len(whitelist) > 0 && len(blacklist) > 0 {
+		return fmt.Errorf("whitelist and blacklist can not be specified at the same time")
+	}
+
+	requestURL := utils.PluginRequestURL(c.String("service_id"), c.String("route_id"))
+
+	acl := ACL{
+		Name:       PLUGIN_ACL,
+		ConsumerID: c.String("consumer_id"),
+		Enabled:    utils.PluginEnabled(c),
+		Config: ACLConfig{
+			Whitelist:        whitelist,
+			Blacklist:        blacklist,
+			HideGroupsHeader: c.Bool("hide_groups_header"),
+		},
+	}
+
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	serverResponse, err := client.GatewayClient.Post(ctx, requestURL, nil, acl, nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := ioutil.ReadAll(serverResponse.Body)
+	if err != nil {
+		return err
+	}
+
+	tools.IndentFromBody(body)
+	return nil
+}
+
+//addConsumerACLGroup associate a group to a consumer.
+func addConsumerACLGroup(c *cli.Context) error {
+	consumer := c.String("consumer")
+	group := c.String("group")
+
+	if consumer == "" || group == "" {
+		return fmt.Errorf("consumer: %s group: %s is not allow empty", consumer, group)
+	}
+
+	requestURL := fmt.Sprintf("consumers/%s/acls", consumer)
+
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	serverResponse, err := client.GatewayClient.Post(ctx, requestURL, nil, &ACLGroup{Group: group}, nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := ioutil.ReadAll(serverResponse.Body)
+	if err != nil {
+		return err
+	}
+
+	tools.IndentFromBody(body)
+	return nil
+}
+
+//removeConsumerACLGroup remove a group from a consumer.
+func removeConsumerACLGroup(c *cli.Context) error {
+	consumer := c.String("consumer")
+	group := c.String("group")
+
+	if consumer == "" || group == "" {
+		return fmt.Errorf("consumer: %s group: %s is not allow empty", consumer, group)
+	}
+
+	if err := tools.ConfirmDestructive(c.Bool("yes"), fmt.Sprintf("remove group %s from consumer %s.", group, consumer), group, false); err != nil {
+		return err
+	}
+
+	requestURL := fmt.Sprintf("consumers/%s/acls/%s", consumer, group)
+
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	serverResponse, err := client.GatewayClient.Delete(ctx, requestURL, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	if serverResponse.StatusCode == http.StatusNoContent {
+		fmt.Printf("remove group %s from consumer %s success.\n", group, consumer)
+	} else {
+		return fmt.Errorf("failed to remove group %s from consumer %s.", group, consumer)
+	}
+
+	return nil
+}
+
+//listConsumerACLGroups list the groups of a consumer.
+func listConsumerACLGroups(c *cli.Context) error {
+	consumer := c.String("consumer")
+	if consumer == "" {
+		return fmt.Errorf("consumer is not allow empty")
+	}
+
+	groups, err := GetConsumerACLGroups(consumer)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-40s\t%-20s\n", "ID", "GROUP")
+	for _, g := range groups {
+		fmt.Printf("%-40s\t%-20s\n", g.ID, g.Group)
+	}
+
+	return nil
+}
+
+//GetConsumerACLGroups retrieve the acl groups associated to a consumer, following the pages of kong.
+func GetConsumerACLGroups(consumer string) ([]ACLGroup, error) {
+	requestURL := fmt.Sprintf("consumers/%s/acls", consumer)
+
+	q := url.Values{}
+	q.Set("size", ACL_GROUPS_PAGE_SIZE)
+
+	var groups []ACLGroup
+	for {
+		page, err := getACLGroupsPage(requestURL, q)
+		if err != nil {
+			return nil, err
+		}
+
+		groups = append(groups, page.Data...)
+		if page.Offset == "" {
+			break
+		}
+		q.Set("offset", page.Offset)
+	}
+
+	return groups, nil
+}
+
+func getACLGroupsPage(requestURL string, q url.Values) (*ACLGroups, error) {
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	serverResponse, err := client.GatewayClient.Get(ctx, requestURL, q, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(serverResponse.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ACLGroups{}
+	if err = json.Unmarshal(body, page); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+//ACLAdmits reports whether a consumer belonging to groups is allowed by the acl config.
+func ACLAdmits(cfg ACLConfig, groups []string) bool {
+	if len(cfg.Whitelist) > 0 {
+		return containsAny(cfg.Whitelist, groups)
+	}
+
+	return !containsAny(cfg.Blacklist, groups)
+}
+
+func containsAny(list []string, groups []string) bool {
+	for _, l := range list {
+		for _, g := range groups {
+			if l == g {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package traffic_control
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/xigang/kongctl/common/client"
+	"github.com/xigang/kongctl/pkg/plugin/utils/plugintest"
+)
+
+func TestCreateACLPlugin(t *testing.T) {
+	cases := []struct {
+		args []string
+		want map[string]interface{}
+	}{
+		{
+			args: []string{"--whitelist", "admin"},
+			want: map[string]interface{}{"name": "acl"},
+		},
+		{
+			args: []string{"--whitelist", "admin", "--consumer_id", "c1", "--enabled=false"},
+			want: map[string]interface{}{"name": "acl", "consumer_id": "c1", "enabled": false},
+		},
+		{
+			args: []string{"--blacklist", "banned", "--enabled"},
+			want: map[string]interface{}{"name": "acl", "enabled": true},
+		},
+	}
+
+	for _, tc := range cases {
+		got := plugintest.PostedPlugin(t, ACLCommand, tc.args...)
+		delete(got, "config")
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%v: got %v, want %v", tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestACLAdmits(t *testing.T) {
+	cases := []struct {
+		cfg    ACLConfig
+		groups []string
+		want   bool
+	}{
+		{ACLConfig{Whitelist: []string{"admin"}}, []string{"ops", "admin"}, true},
+		{ACLConfig{Whitelist: []string{"admin"}}, []string{"ops"}, false},
+		{ACLConfig{Whitelist: []string{"admin"}}, nil, false},
+		{ACLConfig{Blacklist: []string{"banned"}}, []string{"banned"}, false},
+		{ACLConfig{Blacklist: []string{"banned"}}, nil, true},
+	}
+
+	for _, tc := range cases {
+		if got := ACLAdmits(tc.cfg, tc.groups); got != tc.want {
+			t.Errorf("%+v %v: got %v, want %v", tc.cfg, tc.groups, got, tc.want)
+		}
+	}
+}
+
+func TestGetConsumerACLGroups(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/consumers/c1/acls" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.URL.Query().Get("offset") {
+		case "":
+			w.Write([]byte(`{"data":[{"id":"a1","group":"admin"}],"offset":"page2"}`))
+		case "page2":
+			w.Write([]byte(`{"data":[{"id":"a2","group":"ops"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	old := client.GatewayClient
+	defer func() { client.GatewayClient = old }()
+	var err error
+	if client.GatewayClient, err = client.NewHTTPClient(server.URL, map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := GetConsumerACLGroups("c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ACLGroup{{ID: "a1", Group: "admin"}, {ID: "a2", Group: "ops"}}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("got %+v, want %+v", groups, want)
+	}
+}
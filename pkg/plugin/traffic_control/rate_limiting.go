@@ -1 +1,207 @@
 package traffic_control
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/common/client"
+	"github.com/xigang/kongctl/common/tools"
+	"github.com/xigang/kongctl/pkg/plugin/utils"
+)
+
+//Rate Limiting
+//https://docs.konghq.com/hub/kong-inc/rate-limiting/
+
+//Rate limit how many HTTP requests a developer can make in a given period of seconds, minutes, hours, days, months or years.
+//If the underlying Service/Route (or deprecated API entity) has no authentication layer, the Client IP address will be used,
+//otherwise the Consumer will be used if an authentication plugin has been configured.
+
+const (
+	PLUGIN_RATE_LIMITING = "rate-limiting"
+
+	RATE_LIMITING_POLICY_LOCAL   = "local"
+	RATE_LIMITING_POLICY_CLUSTER = "cluster"
+	RATE_LIMITING_POLICY_REDIS   = "redis"
+)
+
+type RateLimiting struct {
+	//The name of the plugin to use, in this case rate-limiting
+	Name string `json:"name"`
+	//Consumer_id is the id of the Consumer we want to associate with this plugin.
+	ConsumerID string             `json:"consumer_id,omitempty"`
+	Enabled    *bool              `json:"enabled,omitempty"`
+	Config     RateLimitingConfig `json:"config"`
+}
+
+type RateLimitingConfig struct {
+	//The amount of HTTP requests the developer can make per second. At least one limit must exist.
+	Second int `json:"second,omitempty"`
+	//The amount of HTTP requests the developer can make per minute. At least one limit must exist.
+	Minute int `json:"minute,omitempty"`
+	//The amount of HTTP requests the developer can make per hour. At least one limit must exist.
+	Hour int `json:"hour,omitempty"`
+	//The amount of HTTP requests the developer can make per day. At least one limit must exist.
+	Day int `json:"day,omitempty"`
+	//The amount of HTTP requests the developer can make per month. At least one limit must exist.
+	Month int `json:"month,omitempty"`
+	//The amount of HTTP requests the developer can make per year. At least one limit must exist.
+	Year int `json:"year,omitempty"`
+	//The entity that will be used when aggregating the limits: consumer, credential, ip.
+	LimitBy string `json:"limit_by,omitempty"`
+	//The rate-limiting policies to use for retrieving and incrementing the limits. Available values are local, cluster and redis.
+	Policy string `json:"policy,omitempty"`
+	//A boolean value that determines if the requests should be proxied even if Kong has troubles connecting a third-party datastore.
+	FaultTolerant bool `json:"fault_tolerant"`
+	//Optionally hide informative response headers.
+	HideClientHeaders bool `json:"hide_client_headers"`
+	//When using the redis policy, this property specifies the address to the Redis server.
+	RedisHost string `json:"redis_host,omitempty"`
+	//When using the redis policy, this property specifies the port of the Redis server.
+	RedisPort int `json:"redis_port,omitempty"`
+	//When using the redis policy, this property specifies the password to connect to the Redis server.
+	RedisPassword string `json:"redis_password,omitempty"`
+	//When using the redis policy, this property specifies the timeout in milliseconds of any command submitted to the Redis server.
+	RedisTimeout int `json:"redis_timeout,omitempty"`
+	//When using the redis policy, this property specifies Redis database to use.
+	RedisDatabase int `json:"redis_database,omitempty"`
+}
+
+var RateLimitingCommand = cli.Command{
+	Name:  "rate-limiting",
+	Usage: "rate limit how many http requests a developer can make in a given period",
+	Flags: append(utils.CommonPluginFlags, []cli.Flag{
+		cli.IntFlag{Name: "second", Usage: "The amount of HTTP requests the developer can make per second"},
+		cli.IntFlag{Name: "minute", Usage: "The amount of HTTP requests the developer can make per minute"},
+		cli.IntFlag{Name: "hour", Usage: "The amount of HTTP requests the developer can make per hour"},
+		cli.IntFlag{Name: "day", Usage: "The amount of HTTP requests the developer can make per day"},
+		cli.IntFlag{Name: "month", Usage: "The amount of HTTP requests the developer can make per month"},
+		cli.IntFlag{Name: "year", Usage: "The amount of HTTP requests the developer can make per year"},
+		cli.StringFlag{Name: "limit_by", Value: "consumer", Usage: "The entity that will be used when aggregating the limits: consumer, credential, ip"},
+		cli.StringFlag{Name: "policy", Value: RATE_LIMITING_POLICY_CLUSTER, Usage: "The rate-limiting policies to use for retrieving and incrementing the limits: local, cluster, redis"},
+		cli.BoolTFlag{Name: "fault_tolerant", Usage: "Proxy the requests even if Kong has troubles connecting a third-party datastore"},
+		cli.BoolFlag{Name: "hide_client_headers", Usage: "Optionally hide informative response headers"},
+		cli.StringFlag{Name: "redis_host", Usage: "When using the redis policy, the address to the Redis server"},
+		cli.IntFlag{Name: "redis_port", Value: 6379, Usage: "When using the redis policy, the port of the Redis server"},
+		cli.StringFlag{Name: "redis_password", Usage: "When using the redis policy, the password to connect to the Redis server"},
+		cli.IntFlag{Name: "redis_timeout", Value: 2000, Usage: "When using the redis policy, the timeout in milliseconds of any command submitted to the Redis server"},
+		cli.IntFlag{Name: "redis_database", Value: 0, Usage: "When using the redis policy, the Redis database to use"},
+	}...),
+	Action: createRateLimitingPlugin,
+}
+
+//createRateLimitingPlugin create a rate-limiting plugin for kong api gateway.
+func createRateLimitingPlugin(c *cli.Context) error {
+	config := RateLimitingConfig{
+		Second:            c.Int("second"),
+		Minute:            c.Int("minute"),
+		Hour:              c.Int("hour"),
+		Day:               c.Int("day"),
+		Month:             c.Int("month"),
+		Year:              c.Int("year"),
+		LimitBy:           c.String("limit_by"),
+		Policy:            c.String("policy"),
+		FaultTolerant:     c.BoolT("fault_tolerant"),
+		HideClientHeaders: c.Bool("hide_client_headers"),
+	}
+
+	if config.Policy == RATE_LIMITING_POLICY_REDIS {
+		config.RedisHost = c.String("redis_host")
+		config.RedisPort = c.Int("redis_port")
+		config.RedisPassword = c.String("redis_password")
+		config.RedisTimeout = c.Int("redis_timeout")
+		config.RedisDatabase = c.Int("redis_database")
+	}
+
+	if err := ValidateRateLimitingConfig(config); err != nil {
+		return err
+	}
+
+	requestURL := utils.PluginRequestURL(c.String("service_id"), c.String("route_id"))
+
+	rateLimiting := RateLimiting{
+		Name:       PLUGIN_RATE_LIMITING,
+		ConsumerID: c.String("consumer_id"),
+		Enabled:    utils.PluginEnabled(c),
+		Config:     config,
+	}
+
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	serverResponse, err := client.GatewayClient.Post(ctx, requestURL, nil, rateLimiting, nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := ioutil.ReadAll(serverResponse.Body)
+	if err != nil {
+		return err
+	}
+
+	tools.IndentFromBody(body)
+	return nil
+}
+
+//ValidateRateLimitingConfig check the config the same way kong does before it is sent to the admin api.
+func ValidateRateLimitingConfig(config RateLimitingConfig) error {
+	windows := []struct {
+		name  string
+		limit int
+	}{
+		{"second", config.Second},
+		{"minute", config.Minute},
+		{"hour", config.Hour},
+		{"day", config.Day},
+		{"month", config.Month},
+		{"year", config.Year},
+	}
+
+	var last string
+	var lastLimit int
+	for _, w := range windows {
+		if w.limit < 0 {
+			return fmt.Errorf("the limit for %s must be a positive number", w.name)
+		}
+
+		if w.limit == 0 {
+			continue
+		}
+
+		if last != "" && w.limit < lastLimit {
+			return fmt.Errorf("the limit for %s(%d) cannot be lower than the limit for %s(%d)", w.name, w.limit, last, lastLimit)
+		}
+		last, lastLimit = w.name, w.limit
+	}
+
+	if last == "" {
+		return fmt.Errorf("you need to set at least one limit: second, minute, hour, day, month, year")
+	}
+
+	switch config.LimitBy {
+	case "consumer", "credential", "ip":
+	default:
+		return fmt.Errorf("limit_by %s is invalid, available values are consumer, credential, ip", config.LimitBy)
+	}
+
+	switch config.Policy {
+	case RATE_LIMITING_POLICY_LOCAL, RATE_LIMITING_POLICY_CLUSTER:
+	case RATE_LIMITING_POLICY_REDIS:
+		if config.RedisHost == "" {
+			return fmt.Errorf("redis_host is required when the policy is redis")
+		}
+		if config.RedisPort <= 0 {
+			return fmt.Errorf("redis_port %d is invalid", config.RedisPort)
+		}
+		if config.RedisTimeout <= 0 {
+			return fmt.Errorf("redis_timeout %d is invalid", config.RedisTimeout)
+		}
+	default:
+		return fmt.Errorf("policy %s is invalid, available values are local, cluster, redis", config.Policy)
+	}
+
+	return nil
+}
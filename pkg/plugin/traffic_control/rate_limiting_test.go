@@ -0,0 +1,50 @@
+package traffic_control
+
+import (
+	"testing"
+
+	"github.com/xigang/kongctl/pkg/plugin/utils/plugintest"
+)
+
+func TestCreateRateLimitingPlugin(t *testing.T) {
+	cases := []struct {
+		args    []string
+		enabled interface{}
+	}{
+		{args: []string{"--minute", "10"}, enabled: nil},
+		{args: []string{"--minute", "10", "--enabled"}, enabled: true},
+		//a disabled plugin, the false value must be sent.
+		{args: []string{"--minute", "10", "--enabled=false"}, enabled: false},
+	}
+
+	for _, tc := range cases {
+		got := plugintest.PostedPlugin(t, RateLimitingCommand, tc.args...)
+		if got["enabled"] != tc.enabled {
+			t.Errorf("%v: got enabled %v, want %v", tc.args, got["enabled"], tc.enabled)
+		}
+	}
+}
+
+func TestValidateRateLimitingConfig(t *testing.T) {
+	cases := []struct {
+		name    string
+		config  RateLimitingConfig
+		wantErr bool
+	}{
+		{"a single limit", RateLimitingConfig{Minute: 10, LimitBy: "consumer", Policy: RATE_LIMITING_POLICY_CLUSTER}, false},
+		{"increasing limits", RateLimitingConfig{Second: 5, Hour: 100, LimitBy: "ip", Policy: RATE_LIMITING_POLICY_LOCAL}, false},
+		{"no limit", RateLimitingConfig{LimitBy: "consumer", Policy: RATE_LIMITING_POLICY_CLUSTER}, true},
+		{"a negative limit", RateLimitingConfig{Minute: -1, LimitBy: "consumer", Policy: RATE_LIMITING_POLICY_CLUSTER}, true},
+		{"a longer window with a lower limit", RateLimitingConfig{Minute: 100, Hour: 50, LimitBy: "consumer", Policy: RATE_LIMITING_POLICY_CLUSTER}, true},
+		{"an invalid limit_by", RateLimitingConfig{Minute: 10, LimitBy: "header", Policy: RATE_LIMITING_POLICY_CLUSTER}, true},
+		{"redis without host", RateLimitingConfig{Minute: 10, LimitBy: "consumer", Policy: RATE_LIMITING_POLICY_REDIS, RedisPort: 6379, RedisTimeout: 2000}, true},
+		{"redis", RateLimitingConfig{Minute: 10, LimitBy: "consumer", Policy: RATE_LIMITING_POLICY_REDIS, RedisHost: "redis", RedisPort: 6379, RedisTimeout: 2000}, false},
+		{"an invalid policy", RateLimitingConfig{Minute: 10, LimitBy: "consumer", Policy: "memcached"}, true},
+	}
+
+	for _, tc := range cases {
+		if err := ValidateRateLimitingConfig(tc.config); (err != nil) != tc.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package transformations
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The preview applies a stored transformer config to a sample http message offline.
+// A sample file is a raw http message, for example
+//
+//	POST /v1/orders?debug=1 HTTP/1.1
+//	Host: api.example.com
+//	Content-Type: application/json
+//
+//	{"id": "1"}
+
+//SampleRequest is a http request read from a sample file.
+type SampleRequest struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Proto  string
+	Header http.Header
+	Body   []byte
+}
+
+//SampleResponse is a http response read from a sample file.
+type SampleResponse struct {
+	Proto  string
+	Status string
+	Header http.Header
+	Body   []byte
+}
+
+//ReadSampleRequest parse a raw http request.
+func ReadSampleRequest(data []byte) (*SampleRequest, error) {
+	head, body := splitMessage(data)
+
+	req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(head)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the sample request: %v", err)
+	}
+
+	//net/http moves the host header out of the header map.
+	if req.Host != "" {
+		req.Header.Set("Host", req.Host)
+	}
+
+	return &SampleRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.Query(),
+		Proto:  req.Proto,
+		Header: req.Header,
+		Body:   body,
+	}, nil
+}
+
+//ReadSampleResponse parse a raw http response.
+func ReadSampleResponse(data []byte) (*SampleResponse, error) {
+	head, body := splitMessage(data)
+
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(head)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the sample response: %v", err)
+	}
+
+	return &SampleResponse{
+		Proto:  resp.Proto,
+		Status: resp.Status,
+		Header: resp.Header,
+		Body:   body,
+	}, nil
+}
+
+//splitMessage split the head and the body of a raw http message, the body is kept verbatim.
+func splitMessage(data []byte) ([]byte, []byte) {
+	data = bytes.TrimLeft(data, "\r\n")
+
+	for _, sep := range []string{"\r\n\r\n", "\n\n"} {
+		if i := bytes.Index(data, []byte(sep)); i >= 0 {
+			return append(data[:i:i], "\r\n\r\n"...), data[i+len(sep):]
+		}
+	}
+
+	return append(bytes.TrimRight(data, "\r\n"), "\r\n\r\n"...), nil
+}
+
+//Write print the request in the http wire format.
+func (r *SampleRequest) Write(w io.Writer) {
+	target := r.Path
+	if len(r.Query) > 0 {
+		target += "?" + r.Query.Encode()
+	}
+
+	fmt.Fprintf(w, "%s %s %s\n", r.Method, target, r.Proto)
+	writeHeadersAndBody(w, r.Header, r.Body)
+}
+
+//Write print the response in the http wire format.
+func (r *SampleResponse) Write(w io.Writer) {
+	fmt.Fprintf(w, "%s %s\n", r.Proto, r.Status)
+	writeHeadersAndBody(w, r.Header, r.Body)
+}
+
+func writeHeadersAndBody(w io.Writer, header http.Header, body []byte) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, v := range header[name] {
+			fmt.Fprintf(w, "%s: %s\n", name, v)
+		}
+	}
+
+	fmt.Fprintf(w, "\n%s\n", body)
+}
+
+//fieldSet is a set of named values a transformer operates on.
+type fieldSet interface {
+	get(name string) (string, bool)
+	set(name, value string)
+	del(name string)
+	appendValue(name, value string)
+}
+
+type headerSet http.Header
+
+func (h headerSet) get(name string) (string, bool) {
+	v, ok := h[http.CanonicalHeaderKey(name)]
+	if !ok || len(v) == 0 {
+		return "", false
+	}
+	return v[0], true
+}
+func (h headerSet) set(name, value string)         { http.Header(h).Set(name, value) }
+func (h headerSet) del(name string)                { http.Header(h).Del(name) }
+func (h headerSet) appendValue(name, value string) { http.Header(h).Add(name, value) }
+
+type valuesSet url.Values
+
+func (v valuesSet) get(name string) (string, bool) {
+	values, ok := v[name]
+	if !ok || len(values) == 0 {
+		return "", false
+	}
+	return values[0], true
+}
+func (v valuesSet) set(name, value string)         { url.Values(v).Set(name, value) }
+func (v valuesSet) del(name string)                { url.Values(v).Del(name) }
+func (v valuesSet) appendValue(name, value string) { url.Values(v).Add(name, value) }
+
+type jsonSet map[string]interface{}
+
+func (j jsonSet) get(name string) (string, bool) {
+	v, ok := j[name]
+	if !ok {
+		return "", false
+	}
+	if s, ok := v.(string); ok {
+		return s, true
+	}
+	b, _ := json.Marshal(v)
+	return string(b), true
+}
+func (j jsonSet) set(name, value string) { j[name] = value }
+func (j jsonSet) del(name string)        { delete(j, name) }
+func (j jsonSet) appendValue(name, value string) {
+	switch v := j[name].(type) {
+	case nil:
+		j[name] = value
+	case []interface{}:
+		j[name] = append(v, value)
+	default:
+		j[name] = []interface{}{v, value}
+	}
+}
+
+//operationFields is the fields of one operation of a transformer config.
+type operationFields struct {
+	operation string
+	fields    TransformerFields
+}
+
+//applyOperations apply the operations, in the order kong runs them, to the values of a target.
+func applyOperations(ops []operationFields, target string, s fieldSet) {
+	for _, op := range ops {
+		for _, pair := range *op.fields.list(target) {
+			name, value := splitPair(pair)
+
+			switch op.operation {
+			case OPERATION_REMOVE:
+				s.del(name)
+			case OPERATION_RENAME:
+				if old, ok := s.get(name); ok {
+					s.del(name)
+					s.set(value, old)
+				}
+			case OPERATION_REPLACE:
+				if _, ok := s.get(name); ok {
+					s.set(name, value)
+				}
+			case OPERATION_ADD:
+				if _, ok := s.get(name); !ok {
+					s.set(name, value)
+				}
+			case OPERATION_APPEND:
+				s.appendValue(name, value)
+			}
+		}
+	}
+}
+
+//applyBody apply the operations to a form or json body, other content types are left untouched.
+func applyBody(ops []operationFields, target string, header http.Header, body []byte) ([]byte, error) {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(strings.TrimSpace(string(body)))
+		if err != nil {
+			return nil, err
+		}
+		applyOperations(ops, target, valuesSet(values))
+		body = []byte(values.Encode())
+	case "application/json":
+		object := map[string]interface{}{}
+		if len(bytes.TrimSpace(body)) > 0 {
+			if err := json.Unmarshal(body, &object); err != nil {
+				return nil, fmt.Errorf("failed to decode the json body: %v", err)
+			}
+		}
+		applyOperations(ops, target, jsonSet(object))
+
+		var err error
+		if body, err = json.Marshal(object); err != nil {
+			return nil, err
+		}
+	default:
+		return body, nil
+	}
+
+	if header.Get("Content-Length") != "" {
+		header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	return body, nil
+}
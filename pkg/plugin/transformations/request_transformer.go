@@ -1 +1,149 @@
 package transformations
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/common/client"
+	"github.com/xigang/kongctl/common/tools"
+	"github.com/xigang/kongctl/pkg/plugin/utils"
+)
+
+//Request Transformer
+//https://docs.konghq.com/hub/kong-inc/request-transformer/
+
+//Transform the request sent by a client on the fly on Kong, before hitting the upstream server.
+
+const (
+	PLUGIN_REQUEST_TRANSFORMER = "request-transformer"
+)
+
+//the targets the request transformer operates on.
+var requestTargets = []string{TARGET_HEADERS, TARGET_QUERYSTRING, TARGET_BODY}
+
+type RequestTransformer struct {
+	//The name of the plugin to use, in this case request-transformer
+	Name string `json:"name"`
+	//Consumer_id is the id of the Consumer we want to associate with this plugin.
+	ConsumerID string                   `json:"consumer_id,omitempty"`
+	Enabled    *bool                    `json:"enabled,omitempty"`
+	Config     RequestTransformerConfig `json:"config"`
+}
+
+type RequestTransformerConfig struct {
+	//Changes the HTTP method for the upstream request.
+	HTTPMethod string `json:"http_method,omitempty"`
+	//Parameters to remove from the request.
+	Remove TransformerFields `json:"remove"`
+	//Parameters to rename in the request, as oldname:newname.
+	Rename TransformerFields `json:"rename"`
+	//Parameters to replace in the request, only if they already exist.
+	Replace TransformerFields `json:"replace"`
+	//Parameters to add to the request, only if they do not exist yet.
+	Add TransformerFields `json:"add"`
+	//Parameters to append to the request, added even if they already exist.
+	Append TransformerFields `json:"append"`
+}
+
+//the operations in the order kong runs them.
+func (cfg RequestTransformerConfig) operations() []operationFields {
+	return []operationFields{
+		{OPERATION_REMOVE, cfg.Remove},
+		{OPERATION_RENAME, cfg.Rename},
+		{OPERATION_REPLACE, cfg.Replace},
+		{OPERATION_ADD, cfg.Add},
+		{OPERATION_APPEND, cfg.Append},
+	}
+}
+
+var RequestTransformerCommand = cli.Command{
+	Name:  "request-transformer",
+	Usage: "transform the request sent by a client before hitting the upstream server",
+	Flags: append(utils.CommonPluginFlags, []cli.Flag{
+		cli.StringFlag{Name: "http_method", Usage: "Changes the HTTP method for the upstream request"},
+		cli.StringSliceFlag{Name: "remove", Usage: "remove a parameter, as header|querystring|body:name"},
+		cli.StringSliceFlag{Name: "rename", Usage: "rename a parameter, as header|querystring|body:old:new"},
+		cli.StringSliceFlag{Name: "replace", Usage: "replace a parameter if it exists, as header|querystring|body:name:value"},
+		cli.StringSliceFlag{Name: "add", Usage: "add a parameter if it does not exist, as header|querystring|body:name:value"},
+		cli.StringSliceFlag{Name: "append", Usage: "append a parameter even if it exists, as header|querystring|body:name:value"},
+	}...),
+	Action: createRequestTransformerPlugin,
+}
+
+//createRequestTransformerPlugin create a request-transformer plugin for kong api gateway.
+func createRequestTransformerPlugin(c *cli.Context) error {
+	config := RequestTransformerConfig{
+		HTTPMethod: strings.ToUpper(c.String("http_method")),
+	}
+
+	rules := 0
+	for _, op := range []struct {
+		operation string
+		fields    *TransformerFields
+	}{
+		{OPERATION_REMOVE, &config.Remove},
+		{OPERATION_RENAME, &config.Rename},
+		{OPERATION_REPLACE, &config.Replace},
+		{OPERATION_ADD, &config.Add},
+		{OPERATION_APPEND, &config.Append},
+	} {
+		if err := addRules(op.fields, op.operation, c.StringSlice(op.operation), requestTargets); err != nil {
+			return err
+		}
+		rules += len(c.StringSlice(op.operation))
+	}
+
+	if rules == 0 && config.HTTPMethod == "" {
+		return fmt.Errorf("at least one of http_method, remove, rename, replace, add, append must be specified")
+	}
+
+	requestURL := utils.PluginRequestURL(c.String("service_id"), c.String("route_id"))
+
+	transformer := RequestTransformer{
+		Name:       PLUGIN_REQUEST_TRANSFORMER,
+		ConsumerID: c.String("consumer_id"),
+		Enabled:    utils.PluginEnabled(c),
+		Config:     config,
+	}
+
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	serverResponse, err := client.GatewayClient.Post(ctx, requestURL, nil, transformer, nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := ioutil.ReadAll(serverResponse.Body)
+	if err != nil {
+		return err
+	}
+
+	tools.IndentFromBody(body)
+	return nil
+}
+
+//ApplyRequestTransformer apply a request-transformer config to a sample request.
+func ApplyRequestTransformer(cfg RequestTransformerConfig, req *SampleRequest) error {
+	ops := cfg.operations()
+
+	applyOperations(ops, TARGET_HEADERS, headerSet(req.Header))
+	applyOperations(ops, TARGET_QUERYSTRING, valuesSet(req.Query))
+
+	body, err := applyBody(ops, TARGET_BODY, req.Header, req.Body)
+	if err != nil {
+		return err
+	}
+	req.Body = body
+
+	if cfg.HTTPMethod != "" {
+		req.Method = cfg.HTTPMethod
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package transformations
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestApplyRequestTransformer(t *testing.T) {
+	sample := "POST /orders?debug=1&page=2 HTTP/1.1\n" +
+		"Host: api.example.com\n" +
+		"Content-Type: application/json\n" +
+		"X-Old: value\n" +
+		"\n" +
+		`{"user":"alice","secret":"s3cret"}`
+
+	cfg := RequestTransformerConfig{
+		HTTPMethod: "PUT",
+		Remove:     TransformerFields{Querystring: StringList{"debug"}, Body: StringList{"secret"}},
+		Rename:     TransformerFields{Headers: StringList{"X-Old:X-New"}},
+		Replace:    TransformerFields{Querystring: StringList{"page:1", "missing:1"}},
+		Add:        TransformerFields{Headers: StringList{"X-New:ignored", "X-Added:yes"}, Body: StringList{"source:kong"}},
+		Append:     TransformerFields{Headers: StringList{"X-Added:again"}},
+	}
+
+	req, err := ReadSampleRequest([]byte(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyRequestTransformer(cfg, req); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	req.Write(buf)
+
+	want := "PUT /orders?page=1 HTTP/1.1\n" +
+		"Content-Type: application/json\n" +
+		"Host: api.example.com\n" +
+		"X-Added: yes\n" +
+		"X-Added: again\n" +
+		"X-New: value\n" +
+		"\n" +
+		`{"source":"kong","user":"alice"}` + "\n"
+	if buf.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", buf, want)
+	}
+}
@@ -1 +1,140 @@
 package transformations
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"time"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/common/client"
+	"github.com/xigang/kongctl/common/tools"
+	"github.com/xigang/kongctl/pkg/plugin/utils"
+)
+
+//Response Transformer
+//https://docs.konghq.com/hub/kong-inc/response-transformer/
+
+//Transform the response sent by the upstream server on the fly on Kong, before returning the response to the client.
+//Kong 0.14 does not support renaming response parameters, so there is no rename operation.
+
+const (
+	PLUGIN_RESPONSE_TRANSFORMER = "response-transformer"
+)
+
+//the targets the response transformer operates on.
+var responseTargets = []string{TARGET_HEADERS, TARGET_JSON}
+
+type ResponseTransformer struct {
+	//The name of the plugin to use, in this case response-transformer
+	Name string `json:"name"`
+	//Consumer_id is the id of the Consumer we want to associate with this plugin.
+	ConsumerID string                    `json:"consumer_id,omitempty"`
+	Enabled    *bool                     `json:"enabled,omitempty"`
+	Config     ResponseTransformerConfig `json:"config"`
+}
+
+type ResponseTransformerConfig struct {
+	//Parameters to remove from the response.
+	Remove TransformerFields `json:"remove"`
+	//Parameters to replace in the response, only if they already exist.
+	Replace TransformerFields `json:"replace"`
+	//Parameters to add to the response, only if they do not exist yet.
+	Add TransformerFields `json:"add"`
+	//Parameters to append to the response, added even if they already exist.
+	Append TransformerFields `json:"append"`
+}
+
+//the operations in the order kong runs them.
+func (cfg ResponseTransformerConfig) operations() []operationFields {
+	return []operationFields{
+		{OPERATION_REMOVE, cfg.Remove},
+		{OPERATION_REPLACE, cfg.Replace},
+		{OPERATION_ADD, cfg.Add},
+		{OPERATION_APPEND, cfg.Append},
+	}
+}
+
+var ResponseTransformerCommand = cli.Command{
+	Name:  "response-transformer",
+	Usage: "transform the response sent by the upstream server before returning it to the client",
+	Flags: append(utils.CommonPluginFlags, []cli.Flag{
+		cli.StringSliceFlag{Name: "remove", Usage: "remove a parameter, as header|json:name"},
+		cli.StringSliceFlag{Name: "replace", Usage: "replace a parameter if it exists, as header|json:name:value"},
+		cli.StringSliceFlag{Name: "add", Usage: "add a parameter if it does not exist, as header|json:name:value"},
+		cli.StringSliceFlag{Name: "append", Usage: "append a parameter even if it exists, as header|json:name:value"},
+	}...),
+	Action: createResponseTransformerPlugin,
+}
+
+//createResponseTransformerPlugin create a response-transformer plugin for kong api gateway.
+func createResponseTransformerPlugin(c *cli.Context) error {
+	config := ResponseTransformerConfig{}
+
+	rules := 0
+	for _, op := range []struct {
+		operation string
+		fields    *TransformerFields
+	}{
+		{OPERATION_REMOVE, &config.Remove},
+		{OPERATION_REPLACE, &config.Replace},
+		{OPERATION_ADD, &config.Add},
+		{OPERATION_APPEND, &config.Append},
+	} {
+		if err := addRules(op.fields, op.operation, c.StringSlice(op.operation), responseTargets); err != nil {
+			return err
+		}
+		rules += len(c.StringSlice(op.operation))
+	}
+
+	if rules == 0 {
+		return fmt.Errorf("at least one of remove, replace, add, append must be specified")
+	}
+
+	requestURL := utils.PluginRequestURL(c.String("service_id"), c.String("route_id"))
+
+	transformer := ResponseTransformer{
+		Name:       PLUGIN_RESPONSE_TRANSFORMER,
+		ConsumerID: c.String("consumer_id"),
+		Enabled:    utils.PluginEnabled(c),
+		Config:     config,
+	}
+
+	ctx, cannel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cannel()
+
+	serverResponse, err := client.GatewayClient.Post(ctx, requestURL, nil, transformer, nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := ioutil.ReadAll(serverResponse.Body)
+	if err != nil {
+		return err
+	}
+
+	tools.IndentFromBody(body)
+	return nil
+}
+
+//ApplyResponseTransformer apply a response-transformer config to a sample response.
+func ApplyResponseTransformer(cfg ResponseTransformerConfig, resp *SampleResponse) error {
+	ops := cfg.operations()
+
+	applyOperations(ops, TARGET_HEADERS, headerSet(resp.Header))
+
+	//kong only transforms json bodies.
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "application/json" {
+		return nil
+	}
+
+	body, err := applyBody(ops, TARGET_JSON, resp.Header, resp.Body)
+	if err != nil {
+		return err
+	}
+	resp.Body = body
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package transformations
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// The transformer flags use a readable rule dsl: <target>:<name>[:<value>], for example
+//
+//	--add header:X-Foo:bar
+//	--remove querystring:debug
+//	--rename body:old_name:new_name
+//
+// Every rule is stored in the kong format (name:value) under config.<operation>.<target>.
+
+const (
+	OPERATION_REMOVE  = "remove"
+	OPERATION_RENAME  = "rename"
+	OPERATION_REPLACE = "replace"
+	OPERATION_ADD     = "add"
+	OPERATION_APPEND  = "append"
+
+	TARGET_HEADERS     = "headers"
+	TARGET_QUERYSTRING = "querystring"
+	TARGET_BODY        = "body"
+	TARGET_JSON        = "json"
+)
+
+//the aliases accepted in the rule dsl for each target.
+var targetAliases = map[string]string{
+	"header":      TARGET_HEADERS,
+	"headers":     TARGET_HEADERS,
+	"querystring": TARGET_QUERYSTRING,
+	"query":       TARGET_QUERYSTRING,
+	"body":        TARGET_BODY,
+	"json":        TARGET_JSON,
+}
+
+//StringList is a list of strings that also accepts the empty object kong returns for empty lists.
+type StringList []string
+
+func (l *StringList) UnmarshalJSON(data []byte) error {
+	if strings.TrimSpace(string(data)) == "{}" {
+		*l = nil
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+type TransformerFields struct {
+	//List of headername[:value] pairs.
+	Headers StringList `json:"headers,omitempty"`
+	//List of queryname[:value] pairs.
+	Querystring StringList `json:"querystring,omitempty"`
+	//List of paramname[:value] pairs, only for the request transformer.
+	Body StringList `json:"body,omitempty"`
+	//List of property[:value] pairs, only for the response transformer.
+	JSON StringList `json:"json,omitempty"`
+}
+
+func (f *TransformerFields) list(target string) *StringList {
+	switch target {
+	case TARGET_HEADERS:
+		return &f.Headers
+	case TARGET_QUERYSTRING:
+		return &f.Querystring
+	case TARGET_BODY:
+		return &f.Body
+	default:
+		return &f.JSON
+	}
+}
+
+//Rule is a single parsed transformer rule.
+type Rule struct {
+	Operation string
+	Target    string
+	Name      string
+	Value     string
+}
+
+//ParseRule parse a rule written as <target>:<name>[:<value>] for the operation.
+func ParseRule(operation, rule string, targets []string) (Rule, error) {
+	parts := strings.SplitN(rule, ":", 3)
+	if len(parts) < 2 || parts[1] == "" {
+		return Rule{}, fmt.Errorf("%s rule %q is invalid, expected <target>:<name>[:<value>]", operation, rule)
+	}
+
+	target, ok := targetAliases[strings.ToLower(parts[0])]
+	if !ok || !contains(targets, target) {
+		return Rule{}, fmt.Errorf("%s rule %q has an unsupported target %s, available targets are %s", operation, rule, parts[0], strings.Join(targets, ", "))
+	}
+
+	r := Rule{Operation: operation, Target: target, Name: parts[1]}
+	if len(parts) == 3 {
+		r.Value = parts[2]
+	}
+
+	switch operation {
+	case OPERATION_REMOVE:
+		if len(parts) == 3 {
+			return Rule{}, fmt.Errorf("remove rule %q does not take a value", rule)
+		}
+	case OPERATION_RENAME:
+		if r.Value == "" {
+			return Rule{}, fmt.Errorf("rename rule %q is invalid, expected <target>:<old name>:<new name>", rule)
+		}
+	default:
+		if len(parts) < 3 {
+			return Rule{}, fmt.Errorf("%s rule %q is invalid, expected <target>:<name>:<value>", operation, rule)
+		}
+	}
+
+	return r, nil
+}
+
+//kong stores every rule as name[:value].
+func (r Rule) kongValue() string {
+	if r.Operation == OPERATION_REMOVE {
+		return r.Name
+	}
+	return r.Name + ":" + r.Value
+}
+
+//addRules parse the rules of a operation and append them to fields.
+func addRules(fields *TransformerFields, operation string, rules []string, targets []string) error {
+	for _, raw := range rules {
+		r, err := ParseRule(operation, raw, targets)
+		if err != nil {
+			return err
+		}
+
+		list := fields.list(r.Target)
+		*list = append(*list, r.kongValue())
+	}
+	return nil
+}
+
+//splitPair split a stored name:value pair.
+func splitPair(pair string) (string, string) {
+	parts := strings.SplitN(pair, ":", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+func contains(list []string, value string) bool {
+	for _, l := range list {
+		if l == value {
+			return true
+		}
+	}
+	return false
+}
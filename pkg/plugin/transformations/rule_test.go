@@ -0,0 +1,73 @@
+package transformations
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseRule(t *testing.T) {
+	targets := []string{TARGET_HEADERS, TARGET_QUERYSTRING, TARGET_BODY}
+
+	cases := []struct {
+		operation, rule string
+		want            Rule
+		wantErr         bool
+	}{
+		{operation: OPERATION_ADD, rule: "header:X-Foo:bar", want: Rule{OPERATION_ADD, TARGET_HEADERS, "X-Foo", "bar"}},
+		{operation: OPERATION_ADD, rule: "Query:page:1", want: Rule{OPERATION_ADD, TARGET_QUERYSTRING, "page", "1"}},
+		{operation: OPERATION_APPEND, rule: "header:X-Url:http://example.com:8000", want: Rule{OPERATION_APPEND, TARGET_HEADERS, "X-Url", "http://example.com:8000"}},
+		{operation: OPERATION_REMOVE, rule: "querystring:debug", want: Rule{OPERATION_REMOVE, TARGET_QUERYSTRING, "debug", ""}},
+		{operation: OPERATION_RENAME, rule: "body:old:new", want: Rule{OPERATION_RENAME, TARGET_BODY, "old", "new"}},
+		{operation: OPERATION_REPLACE, rule: "header:X-Empty:", want: Rule{OPERATION_REPLACE, TARGET_HEADERS, "X-Empty", ""}},
+		{operation: OPERATION_REMOVE, rule: "querystring:debug:1", wantErr: true},
+		{operation: OPERATION_RENAME, rule: "body:old", wantErr: true},
+		{operation: OPERATION_ADD, rule: "header:X-Foo", wantErr: true},
+		{operation: OPERATION_ADD, rule: "header:", wantErr: true},
+		{operation: OPERATION_ADD, rule: "cookie:a:b", wantErr: true},
+		{operation: OPERATION_ADD, rule: "json:a:b", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		got, err := ParseRule(tc.operation, tc.rule, targets)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s %s: got error %v, want error %v", tc.operation, tc.rule, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s %s: got %+v, want %+v", tc.operation, tc.rule, got, tc.want)
+		}
+	}
+}
+
+func TestAddRules(t *testing.T) {
+	fields := TransformerFields{}
+	rules := []string{"header:X-Foo:bar", "query:page:1", "header:X-Bar:baz"}
+	if err := addRules(&fields, OPERATION_ADD, rules, []string{TARGET_HEADERS, TARGET_QUERYSTRING}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := TransformerFields{Headers: StringList{"X-Foo:bar", "X-Bar:baz"}, Querystring: StringList{"page:1"}}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("got %+v, want %+v", fields, want)
+	}
+}
+
+func TestStringListUnmarshal(t *testing.T) {
+	cases := map[string]StringList{
+		`{}`:          nil,
+		`[]`:          StringList{},
+		`["a:1","b"]`: StringList{"a:1", "b"},
+	}
+
+	for data, want := range cases {
+		var got StringList
+		if err := json.Unmarshal([]byte(data), &got); err != nil {
+			t.Errorf("%s: %v", data, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %#v, want %#v", data, got, want)
+		}
+	}
+}
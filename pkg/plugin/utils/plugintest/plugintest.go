@@ -0,0 +1,50 @@
+//Package plugintest holds the helpers of the plugin command tests.
+package plugintest
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/urfave/cli"
+
+	"github.com/xigang/kongctl/common/client"
+)
+
+//PostedPlugin run the action of a plugin command with the args and return the plugin it posted.
+func PostedPlugin(t *testing.T, command cli.Command, args ...string) map[string]interface{} {
+	posted := map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &posted); err != nil {
+			t.Errorf("%s: %v", body, err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	old := client.GatewayClient
+	defer func() { client.GatewayClient = old }()
+	var err error
+	if client.GatewayClient, err = client.NewHTTPClient(server.URL, map[string]string{}); err != nil {
+		t.Fatal(err)
+	}
+
+	set := flag.NewFlagSet(command.Name, flag.ContinueOnError)
+	for _, f := range command.Flags {
+		f.Apply(set)
+	}
+	if err := set.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	action := command.Action.(func(c *cli.Context) error)
+	if err := action(cli.NewContext(cli.NewApp(), set, nil)); err != nil {
+		t.Fatal(err)
+	}
+	return posted
+}
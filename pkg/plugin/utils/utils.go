@@ -1,12 +1,24 @@
 package utils
 
 import (
+	"fmt"
+
 	"github.com/urfave/cli"
 )
 
 var AvaliblePlugins map[string]string = map[string]string{
-	"basic-auth": "The plugin will check for valid credentials in the Proxy-Authorization and Authorization header",
-	"statsd":     "Log metrics for a Service, Route to a StatsD server",
+	"basic-auth":           "The plugin will check for valid credentials in the Proxy-Authorization and Authorization header",
+	"statsd":               "Log metrics for a Service, Route to a StatsD server",
+	"acl":                  "Restrict access to a Service or a Route by whitelisting or blacklisting consumers using arbitrary ACL group names",
+	"rate-limiting":        "Rate limit how many HTTP requests a developer can make in a given period",
+	"cors":                 "Add Cross-origin resource sharing (CORS) to a Service or a Route",
+	"request-transformer":  "Transform the request sent by a client on the fly on Kong, before hitting the upstream server",
+	"response-transformer": "Transform the response sent by the upstream server on the fly on Kong, before returning the response to the client",
+	"prometheus":           "Expose metrics related to Kong and proxied upstream services in Prometheus exposition format",
+	"zipkin":               "Propagate Zipkin distributed tracing spans, and report spans to a Zipkin server",
+	"aws-lambda":           "Invoke an AWS Lambda function from Kong",
+	"pre-function":         "Dynamically run Lua code from Kong, before other plugins in each phase",
+	"post-function":        "Dynamically run Lua code from Kong, after other plugins in each phase",
 }
 
 var CommonPluginFlags = []cli.Flag{
@@ -35,3 +47,26 @@ var CommonPluginFlags = []cli.Flag{
 		Usage: "whether the plugin is applied",
 	},
 }
+
+//PluginRequestURL returns the admin api path used to enable a plugin on a service, a route or globally.
+func PluginRequestURL(serviceID, routeID string) string {
+	if serviceID != "" {
+		//Enabling the plugin on a Service
+		return fmt.Sprintf("services/%s/plugins", serviceID)
+	} else if routeID != "" {
+		//Enabling the plugin on a Route
+		return fmt.Sprintf("routes/%s/plugins", routeID)
+	}
+
+	//global plugin
+	return "plugins"
+}
+
+//PluginEnabled return the enabled flag when it is set, nil lets kong keep its default.
+func PluginEnabled(c *cli.Context) *bool {
+	if !c.IsSet("enabled") {
+		return nil
+	}
+	enabled := c.Bool("enabled")
+	return &enabled
+}